@@ -0,0 +1,75 @@
+/*
+Copyright © 2024 Ken Goettler <goettlek@gmail.com>
+*/
+//nolint: gochecknoglobals, gochecknoinits // not applicable to cobra-cli files
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kgoettler/twe/internal/timecard"
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	"github.com/spf13/cobra"
+)
+
+var compareOptions timecard.TimecardOptions
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <rangeA> <rangeB>",
+	Short: "Compare the time recorded for each tag over two ranges",
+	Long: `Prints the total time recorded for each tag over two ranges, with the change
+from the first range to the second (both absolute and as a percentage) and the
+tags which appeared or disappeared.
+
+Each range is a single argument, quoted if it contains spaces:
+
+	twe compare :lastweek :week
+	twe compare "2025-01-01 - 2025-02-01" "2026-01-01 - 2026-02-01"
+	twe compare :period=payroll-1 :period=payroll`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if compareOptions.InputFile != "" {
+			handleError(cmd, "compare reads both ranges from Timewarrior and does not support --file")
+			os.Exit(1)
+		}
+		reports := make([]*timew.Report, len(args))
+		for i, arg := range args {
+			tw, err := loadReport("", strings.Fields(arg))
+			if err != nil {
+				handleError(cmd, "%s", err)
+				os.Exit(1)
+			}
+			reports[i] = tw
+		}
+		compareOptions.OutputFormat = strings.ToLower(compareOptions.OutputFormat)
+
+		msg, err := timecard.RunCompare(reports[0], reports[1], compareOptions)
+		if err != nil {
+			handleError(cmd, "%s", err)
+			os.Exit(1)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), msg)
+		fmt.Fprint(cmd.OutOrStdout(), "\n")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(compareCmd)
+	addDataFlags(compareCmd, &compareOptions)
+	_ = compareCmd.Flags().MarkHidden("file")
+	compareCmd.Flags().StringVar(
+		&compareOptions.OutputFormat,
+		"format",
+		"table",
+		"Output format for report (options: table, csv, tsv, json)",
+	)
+	compareCmd.Flags().StringVar(
+		&compareOptions.Units,
+		"units",
+		timecard.UnitsDecimal,
+		"Units in which durations are displayed (options: decimal, hm, minutes, percent-of-day, percent-of-total)",
+	)
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var editForce bool
+
 var editCmd = &cobra.Command{
 	Use:   "edit",
 	Args:  cobra.MaximumNArgs(1),
@@ -33,8 +35,12 @@ var editCmd = &cobra.Command{
 			defer f.Close()
 		}
 
-		// Setup CLI backend
+		// Setup CLI backend, guarded against changes to locked periods
 		cli := timew.NewCLI()
+		backend, err := guardedBackend(&cli, editForce)
+		if err != nil {
+			handleError(cmd, "%s", err)
+		}
 
 		// Parse date argument (if provided)
 		var dateString string
@@ -53,8 +59,13 @@ var editCmd = &cobra.Command{
 			date = time.Now()
 		}
 
+		// Undo is assumed to revert changes to the day being edited
+		y, mo, d := date.Date()
+		day := time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+		backend.SetUndoRange(day, day.AddDate(0, 0, 1))
+
 		// Setup application model
-		m, err := edit.NewModel(&cli, date, f)
+		m, err := edit.NewModel(backend, date, f)
 		if err != nil {
 			handleError(cmd, "initializing application: %v", err)
 			os.Exit(1)
@@ -70,4 +81,10 @@ var editCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(editCmd)
+	editCmd.Flags().BoolVar(
+		&editForce,
+		"force",
+		false,
+		"Allow changes to intervals in locked periods",
+	)
 }
@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Ken Goettler <goettlek@gmail.com>
+*/
+//nolint: gochecknoglobals // not applicable to cobra-cli files
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	"github.com/spf13/cobra"
+)
+
+// Reports which can be run as Timewarrior extensions
+var extensionReports = []string{"timecard", "invoice"}
+
+// Report read from STDIN when twe runs as a Timewarrior extension
+var extensionReport *timew.Report
+
+// Returns the name of the report to run if twe was invoked as a Timewarrior
+// extension, i.e. if the executable (or a symlink to it, e.g. in the
+// extensions directory) is named after a report (e.g. `timecard` or
+// `twe-timecard`).
+func extensionName(argv0 string) (string, bool) {
+	base := filepath.Base(argv0)
+	name := strings.TrimPrefix(strings.TrimSuffix(base, filepath.Ext(base)), "twe-")
+	if slices.Contains(extensionReports, name) {
+		return name, true
+	}
+	return "", false
+}
+
+// Run the named report on the data passed by Timewarrior on STDIN. Flags are
+// taken from the `reports.<name>.<flag>` settings in timewarrior.cfg (e.g.
+// `reports.timecard.total-row = yes`).
+func executeExtension(name string) error {
+	tw, err := timew.NewReport(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("parsing report: %w", err)
+	}
+	cmd, _, err := RootCmd.Find([]string{name})
+	if err != nil {
+		return err
+	}
+	if err := setExtensionFlags(cmd, name, tw.Config); err != nil {
+		return err
+	}
+	extensionReport = tw
+	RootCmd.SetArgs([]string{name})
+	return RootCmd.Execute()
+}
+
+// Set the flags of cmd from the `reports.<name>.<flag>` settings in config.
+// Settings which are not flags of cmd are left to Timewarrior (e.g.
+// `reports.timecard.range`) and only reported with a warning.
+func setExtensionFlags(cmd *cobra.Command, name string, config map[string]string) error {
+	prefix := "reports." + name + "."
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		flagName, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		flag := cmd.Flags().Lookup(flagName)
+		if flag == nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: ignoring %s: not an option of twe %s\n", key, name)
+			continue
+		}
+		value := config[key]
+		if flag.Value.Type() == "bool" {
+			value = extensionBool(value)
+		}
+		if err := cmd.Flags().Set(flagName, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Convert a Timewarrior boolean setting (e.g. `on`, `yes` or `1`) to a value
+// accepted by a boolean flag.
+func extensionBool(value string) string {
+	switch strings.ToLower(value) {
+	case "on", "yes", "y", "1", "true":
+		return "true"
+	case "off", "no", "n", "0", "false":
+		return "false"
+	default:
+		return value
+	}
+}
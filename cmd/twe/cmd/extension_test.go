@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExtensionSuite struct {
+	suite.Suite
+}
+
+func TestExtensionSuite(t *testing.T) {
+	suite.Run(t, new(ExtensionSuite))
+}
+
+func (suite *ExtensionSuite) TestExtensionName() {
+	tcs := []struct {
+		argv0 string
+		name  string
+		ok    bool
+	}{
+		{"twe", "", false},
+		{"/usr/local/bin/twe", "", false},
+		{"/home/me/.timewarrior/extensions/timecard", "timecard", true},
+		{"/home/me/.timewarrior/extensions/twe-invoice", "invoice", true},
+		{"/home/me/.timewarrior/extensions/timecard.exe", "timecard", true},
+		{"/home/me/.timewarrior/extensions/totals.py", "", false},
+		{"/home/me/.timewarrior/extensions/twe", "", false},
+		{"twe-compare", "", false},
+	}
+	for _, tc := range tcs {
+		name, ok := extensionName(tc.argv0)
+		suite.Equal(tc.name, name, tc.argv0)
+		suite.Equal(tc.ok, ok, tc.argv0)
+	}
+}
+
+func (suite *ExtensionSuite) TestExtensionBool() {
+	tcs := []struct {
+		value    string
+		expected string
+	}{
+		{"on", "true"},
+		{"Yes", "true"},
+		{"y", "true"},
+		{"1", "true"},
+		{"TRUE", "true"},
+		{"off", "false"},
+		{"no", "false"},
+		{"N", "false"},
+		{"0", "false"},
+		{"false", "false"},
+		{"maybe", "maybe"},
+	}
+	for _, tc := range tcs {
+		suite.Equal(tc.expected, extensionBool(tc.value), tc.value)
+	}
+}
+
+func (suite *ExtensionSuite) TestSetExtensionFlags() {
+	cmd := &cobra.Command{Use: "timecard"}
+	totalRow := cmd.Flags().Bool("total-row", false, "")
+	units := cmd.Flags().String("units", "decimal", "")
+	increment := cmd.Flags().Int("increment", 6, "")
+	stderr := new(bytes.Buffer)
+	cmd.SetErr(stderr)
+
+	err := setExtensionFlags(cmd, "timecard", map[string]string{
+		"reports.timecard.total-row": "yes",
+		"reports.timecard.units":     "hm",
+		"reports.timecard.range":     ":week",
+		"reports.invoice.increment":  "15",
+		"verbose":                    "off",
+	})
+	suite.Require().NoError(err)
+	suite.True(*totalRow)
+	suite.Equal("hm", *units)
+	suite.Equal(6, *increment)
+	suite.Equal("warning: ignoring reports.timecard.range: not an option of twe timecard\n", stderr.String())
+
+	err = setExtensionFlags(cmd, "timecard", map[string]string{"reports.timecard.increment": "lots"})
+	suite.ErrorContains(err, "reports.timecard.increment")
+}
@@ -16,6 +16,9 @@ import (
 
 type ImportOptions struct {
 	InputFile string
+
+	// If true, intervals are imported into locked periods
+	Force bool
 }
 
 var importOptions ImportOptions
@@ -58,8 +61,13 @@ var importCmd = &cobra.Command{
 
 		// Import intervals one-by-one
 		cli := timew.NewCLI()
+		backend, err := guardedBackend(&cli, importOptions.Force)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+			return
+		}
 		for _, interval := range input {
-			err := cli.Track(interval)
+			err := backend.Track(interval)
 			if err != nil {
 				fmt.Fprintf(cmd.ErrOrStderr(), "unable to import interval %d: %v\n", interval.ID, err)
 			}
@@ -77,4 +85,10 @@ func init() {
 		"",
 		"Input file to read from. If none specified, will read from STDIN.",
 	)
+	importCmd.Flags().BoolVar(
+		&importOptions.Force,
+		"force",
+		false,
+		"Import intervals into locked periods",
+	)
 }
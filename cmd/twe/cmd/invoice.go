@@ -0,0 +1,57 @@
+/*
+Copyright © 2024 Ken Goettler <goettlek@gmail.com>
+*/
+//nolint: gochecknoglobals, gochecknoinits // not applicable to cobra-cli files
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kgoettler/twe/internal/timecard"
+
+	"github.com/spf13/cobra"
+)
+
+var invoiceOptions timecard.TimecardOptions
+
+var invoiceCmd = &cobra.Command{
+	Use:   "invoice",
+	Short: "Invoice of the amounts billed to each client",
+	Long: `Prints the amounts billed for each tag at the rates defined by twe.rate.<name>
+settings, with subtotals for each client (i.e. each top-level group or tag).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tw, err := loadReport(invoiceOptions.InputFile, args)
+		if err != nil {
+			handleError(cmd, "%s", err)
+			os.Exit(1)
+		}
+		invoiceOptions.OutputFormat = strings.ToLower(invoiceOptions.OutputFormat)
+
+		msg, err := timecard.RunInvoice(tw, invoiceOptions)
+		if err != nil {
+			handleError(cmd, "%s", err)
+			os.Exit(1)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), msg)
+		fmt.Fprint(cmd.OutOrStdout(), "\n")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(invoiceCmd)
+	addDataFlags(invoiceCmd, &invoiceOptions)
+	invoiceCmd.Flags().StringVar(
+		&invoiceOptions.OutputFormat,
+		"format",
+		"markdown",
+		"Output format for invoice (options: markdown, html, json)",
+	)
+	invoiceCmd.Flags().BoolVar(
+		&invoiceOptions.HTMLStyle,
+		"html-css",
+		false,
+		"Include inline CSS in HTML output",
+	)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 Ken Goettler <goettlek@gmail.com>
+*/
+//nolint: gochecknoglobals, gochecknoinits // not applicable to cobra-cli files
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kgoettler/twe/internal/lock"
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	"github.com/spf13/cobra"
+)
+
+var lockList bool
+
+var lockCmd = &cobra.Command{
+	Use:   "lock [range]",
+	Short: "Lock a period so its intervals cannot be changed",
+	Long: `Locks a period (the current week by default), e.g. once its timesheet has
+been submitted. twe edit and twe import refuse to change intervals in locked
+periods unless --force is given, and twe timecard marks them as submitted.
+
+Periods are widened to whole days, and locks which overlap are merged. Locks
+are recorded in the file set by twe.locks in timewarrior.cfg (twe.locks in the
+Timewarrior database directory by default).
+
+	twe lock :lastweek
+	twe lock :period=payroll-1
+	twe lock --list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli := timew.NewCLI()
+		path := locksPath(&cli)
+		locks, err := lock.Read(path)
+		if err != nil {
+			handleError(cmd, "reading locks: %s", err)
+		}
+		if lockList {
+			for _, l := range locks.Entries {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s (locked %s)\n", l, l.Locked.Format(time.DateOnly))
+			}
+			return
+		}
+		start, end, err := loadRange(args)
+		if err != nil {
+			handleError(cmd, "%s", err)
+		}
+		l := locks.Lock(start, end, time.Now())
+		if err := locks.Write(path); err != nil {
+			handleError(cmd, "writing locks: %s", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Locked %s\n", l)
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock [range]",
+	Short: "Unlock the locked periods which overlap a range",
+	Long: `Unlocks the locked periods which overlap a range (the current week by
+default), so their intervals can be changed again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli := timew.NewCLI()
+		path := locksPath(&cli)
+		locks, err := lock.Read(path)
+		if err != nil {
+			handleError(cmd, "reading locks: %s", err)
+		}
+		start, end, err := loadRange(args)
+		if err != nil {
+			handleError(cmd, "%s", err)
+		}
+		removed := locks.Unlock(start, end)
+		if len(removed) == 0 {
+			handleError(cmd, "no locked period overlaps %s to %s", start.Format(time.DateOnly), end.Add(-time.Nanosecond).Format(time.DateOnly))
+		}
+		if err := locks.Write(path); err != nil {
+			handleError(cmd, "writing locks: %s", err)
+		}
+		for _, l := range removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "Unlocked %s\n", l)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(lockCmd)
+	RootCmd.AddCommand(unlockCmd)
+	lockCmd.Flags().BoolVar(
+		&lockList,
+		"list",
+		false,
+		"List the locked periods instead of locking one",
+	)
+}
+
+// Returns the start and end of the given range (the current week by
+// default), in local time.
+func loadRange(args []string) (time.Time, time.Time, error) {
+	tw, err := loadReport("", args)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start, end, err := tw.GetDateRange()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("range has no start or end: %w", err)
+	}
+	return start.Time.Local(), end.Time.Local(), nil
+}
+
+// Returns the path of the lock file, as set by `twe.locks` in
+// timewarrior.cfg. The default is looked up in the database directory
+// Timewarrior uses, which reports see as temp.db, so both agree on the file.
+func locksPath(cli *timew.CLI) string {
+	// `timew get` fails for settings which are not defined
+	setting, _ := cli.Get("dom.rc." + lock.Config)
+	db, _ := cli.Get("dom.rc.temp.db")
+	return lock.Path(setting, db)
+}
+
+// Returns a backend which refuses to change intervals in locked periods
+// unless force is set.
+func guardedBackend(cli *timew.CLI, force bool) (*lock.Guard, error) {
+	locks, err := lock.Read(locksPath(cli))
+	if err != nil {
+		return nil, fmt.Errorf("reading locks: %w", err)
+	}
+	return lock.NewGuard(cli, locks, force), nil
+}
+
+// Returns the locked periods recorded in the lock file set by the report
+// configuration. If the lock file cannot be read, a warning is printed and
+// nil is returned, so no periods are marked as submitted.
+func reportLocks(cmd *cobra.Command, config map[string]string) *lock.Locks {
+	locks, err := lock.Read(lock.Path(config[lock.Config], config["temp.db"]))
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: reading locks: %s\n", err)
+		return nil
+	}
+	return locks
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 Ken Goettler <goettlek@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// Pager used if $PAGER is not set. -R passes colors through, -F exits
+// immediately if the output fits on the screen and -X leaves it on the screen
+// after exiting.
+const defaultPager = "less -RFX"
+
+// Returns the size of the terminal the command writes to, or false if it does
+// not write to a terminal.
+func terminalSize(cmd *cobra.Command) (int, int, bool) {
+	if cmd.OutOrStdout() != os.Stdout || !term.IsTerminal(os.Stdout.Fd()) {
+		return 0, 0, false
+	}
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// Write the output of a command, piping it through a pager if it is taller
+// than the terminal.
+func writePaged(cmd *cobra.Command, msg string, usePager bool) {
+	_, height, ok := terminalSize(cmd)
+	if !usePager || !ok || strings.Count(msg, "\n")+1 < height {
+		fmt.Fprintln(cmd.OutOrStdout(), msg)
+		return
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+	args := strings.Fields(pager)
+	p := exec.Command(args[0], args[1:]...)
+	p.Stdin = strings.NewReader(msg + "\n")
+	p.Stdout = os.Stdout
+	p.Stderr = os.Stderr
+	if err := p.Run(); err != nil {
+		// Fall back to writing the output directly
+		fmt.Fprintln(cmd.OutOrStdout(), msg)
+	}
+}
@@ -0,0 +1,289 @@
+/*
+Copyright © 2024 Ken Goettler <goettlek@gmail.com>
+*/
+//nolint: gochecknoglobals, gochecknoinits // not applicable to cobra-cli files
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kgoettler/twe/internal/period"
+	"github.com/kgoettler/twe/internal/timecard"
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	"github.com/spf13/cobra"
+)
+
+var reportOptions timecard.TimecardOptions
+
+var reportCmd = &cobra.Command{
+	Use:   "report --template <file>",
+	Short: "Report rendered with a user-defined template",
+	Long: `Renders the intervals, rows and totals of the report with a Go text/template,
+for output formats such as standup notes, client emails or chat messages.
+
+Templates are executed with the fields Start, End, Columns, Rows, Totals,
+Total, Intervals, Config and Warnings, and can use the functions duration,
+hours, hm, minutes, percent, date, isodate, day, byTag, byDate, sum, join,
+upper and lower. For example:
+
+	{{range byDate .Intervals}}{{.Key}} ({{hm .Total}})
+	{{range .Intervals}}  - {{join ", " .Tags}}: {{hm .Duration}}
+	{{end}}{{end}}`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reportOptions.Template == "" {
+			handleError(cmd, "no template given: use --template <file>")
+		}
+		tw, err := loadReport(reportOptions.InputFile, args)
+		if err != nil {
+			handleError(cmd, "%s", err)
+			os.Exit(1)
+		}
+
+		msg, err := timecard.Run(tw, reportOptions)
+		if err != nil {
+			handleError(cmd, "%s", err)
+			os.Exit(1)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), msg)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(reportCmd)
+	addDataFlags(reportCmd, &reportOptions)
+	addTemplateFlag(reportCmd, &reportOptions)
+	reportCmd.Flags().StringVar(
+		&reportOptions.Units,
+		"units",
+		timecard.UnitsDecimal,
+		"Units in which the duration template function formats durations (options: decimal, hm, minutes)",
+	)
+}
+
+// Add the flag which renders a command built on timecard data with a
+// user-defined template.
+func addTemplateFlag(cmd *cobra.Command, options *timecard.TimecardOptions) {
+	cmd.Flags().StringVar(
+		&options.Template,
+		"template",
+		"",
+		"Render the report with a Go text/template file instead of --format",
+	)
+}
+
+// Load the Timewarrior report to run a command on. The report is the one
+// passed on STDIN if twe runs as a Timewarrior extension; otherwise it is read
+// from the input file if one is given, or generated by running the `echo`
+// report on the given range (the current week by default). The echo report
+// is used rather than `timew export` as it also passes the full
+// configuration, which `timew get` can only return setting by setting.
+func loadReport(inputFile string, args []string) (*timew.Report, error) {
+	if extensionReport != nil {
+		return extensionReport, nil
+	}
+	var reader io.Reader
+	if inputFile != "" {
+		file, err := os.Open(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("opening input file %s: %w", inputFile, err)
+		}
+		defer file.Close()
+		reader = file
+	} else {
+		// Get Intervals from export
+		if len(args) == 0 {
+			args = append(args, ":week")
+		}
+		cli := timew.NewCLI()
+		args, err := resolvePeriods(&cli, time.Now(), args)
+		if err != nil {
+			return nil, err
+		}
+		reader, err = cli.Report(append([]string{"echo"}, args...)...)
+		if err != nil {
+			return nil, fmt.Errorf("running 'echo' report: %w", err)
+		}
+	}
+
+	// Create timewarrior report object
+	tw, err := timew.NewReport(reader)
+	if err != nil {
+		return nil, fmt.Errorf("parsing 'echo' output: %w", err)
+	}
+	return tw, nil
+}
+
+// Replace any `:period=<name>[+-N]` arguments with the date range of the
+// referenced period, as defined by `twe.period.<name>` in timewarrior.cfg.
+func resolvePeriods(cli *timew.CLI, now time.Time, args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		ref, ok := strings.CutPrefix(arg, ":period=")
+		if !ok {
+			out = append(out, arg)
+			continue
+		}
+		start, end, err := period.Resolve(now, ref, func(name string) (string, error) {
+			return cli.Get("dom.rc." + period.ConfigPrefix + name)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("resolving period %s: %w", ref, err)
+		}
+		out = append(out, start.Format("2006-01-02"), "-", end.Format("2006-01-02"))
+	}
+	return out, nil
+}
+
+// Add the flags which control how intervals are filtered, grouped, rounded
+// and billed to a command built on timecard data.
+func addDataFlags(cmd *cobra.Command, options *timecard.TimecardOptions) {
+	cmd.Flags().IntVar(
+		&options.Increment,
+		"increment",
+		6,
+		"Increment up to which each duration will be rounded (in minutes)",
+	)
+	cmd.Flags().StringVar(
+		&options.Rounding,
+		"rounding",
+		timecard.RoundUp,
+		"How durations are rounded to the increment (options: up, down, nearest, bankers)",
+	)
+	cmd.Flags().StringVar(
+		&options.RoundingScope,
+		"rounding-scope",
+		timecard.ScopeInterval,
+		"What is rounded to the increment (options: interval, cell, week, total)",
+	)
+	cmd.Flags().BoolVar(
+		&options.Reconcile,
+		"reconcile",
+		false,
+		"Adjust rounded cells so they add up to the rounded tag totals (not with --rounding-scope interval)",
+	)
+	cmd.Flags().StringVar(
+		&options.InputFile,
+		"file",
+		"",
+		"Input file to read from. If none specified, will read from STDIN.",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.Filters,
+		"filter",
+		[]string{},
+		"List of filters to apply to tags. Regular expressions are supported",
+	)
+	cmd.Flags().StringVar(
+		&options.FilterMode,
+		"filter-mode",
+		timecard.FilterAny,
+		"Whether intervals must match any or all of the filters (options: any, all)",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.Excludes,
+		"exclude",
+		[]string{},
+		"Exclude intervals with tags matching this regular expression (may be repeated)",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.AnnotationFilters,
+		"annotation",
+		[]string{},
+		"Only include intervals with annotations matching this regular expression (may be repeated)",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.AnnotationExcludes,
+		"exclude-annotation",
+		[]string{},
+		"Exclude intervals with annotations matching this regular expression (may be repeated)",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.Groups,
+		"group",
+		[]string{},
+		"Group tags into a single row (e.g. 'ClientA=^acme-.*' or 'Clients/ClientA=acme-web,acme-api')",
+	)
+	cmd.Flags().BoolVar(
+		&options.ShowGroupTags,
+		"group-tags",
+		false,
+		"Show tags as rows under their group",
+	)
+	cmd.Flags().StringVar(
+		&options.GroupSeparator,
+		"group-separator",
+		"",
+		"Roll up hierarchical tags split by this separator (e.g. '.' for 'acme.web.frontend')",
+	)
+	cmd.Flags().IntVar(
+		&options.GroupDepth,
+		"group-depth",
+		0,
+		"Maximum depth to which hierarchical tags are rolled up (0 for unlimited)",
+	)
+	cmd.Flags().StringVar(
+		&options.Allocation,
+		"allocation",
+		timecard.AllocateFull,
+		"How time is allocated to intervals with multiple tags (options: full, split, primary)",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.PrimaryTags,
+		"primary",
+		[]string{},
+		"Tag which takes priority as the primary tag (may be repeated, in order of priority)",
+	)
+	cmd.Flags().StringVar(
+		&options.PrimaryPattern,
+		"primary-pattern",
+		"",
+		"Regular expression used to choose the primary tag",
+	)
+	cmd.Flags().StringVar(
+		&options.OpenPolicy,
+		"open",
+		timecard.OpenNow,
+		"How running intervals are counted (options: now, range, day, exclude)",
+	)
+	cmd.Flags().DurationVar(
+		&options.OpenWarning,
+		"open-warning",
+		12*time.Hour,
+		"Warn about intervals which have been running for longer than this (0 to disable)",
+	)
+	cmd.Flags().BoolVar(
+		&options.MergeOverlaps,
+		"merge-overlaps",
+		false,
+		"Count time recorded by overlapping intervals only once in the daily totals",
+	)
+	cmd.Flags().BoolVar(
+		&options.Mapped,
+		"mapped",
+		false,
+		"Replace tags with their charge codes from the mapping file",
+	)
+	cmd.Flags().StringVar(
+		&options.MappingFile,
+		"mapping",
+		"",
+		"Charge code mapping file (defaults to the twe.mapping setting)",
+	)
+	cmd.Flags().StringArrayVar(
+		&options.Rates,
+		"rate",
+		[]string{},
+		"Hourly rate of a tag or group (e.g. 'Clients/Acme=150'; may be repeated)",
+	)
+	cmd.Flags().StringVar(
+		&options.Currency,
+		"currency",
+		"",
+		"Currency of the hourly rates (defaults to the twe.currency setting)",
+	)
+}
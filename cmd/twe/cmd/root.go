@@ -20,6 +20,13 @@ var RootCmd = &cobra.Command{
 }
 
 func Execute() {
+	if name, ok := extensionName(os.Args[0]); ok {
+		if err := executeExtension(name); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	err := RootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
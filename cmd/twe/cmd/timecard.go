@@ -6,17 +6,25 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"os"
+	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/kgoettler/twe/internal/browse"
 	"github.com/kgoettler/twe/internal/timecard"
 	timew "github.com/kgoettler/twe/pkg/timewarrior"
 
+	tea "github.com/charmbracelet/bubbletea"
+
 	"github.com/spf13/cobra"
 )
 
-var timecardOptions timecard.TimecardOptions
+var (
+	timecardOptions     timecard.TimecardOptions
+	timecardNoPager     bool
+	timecardInteractive bool
+)
 
 var timecardCmd = &cobra.Command{
 	Use:   "timecard",
@@ -25,37 +33,25 @@ var timecardCmd = &cobra.Command{
 	
 	Useful for copying into a timecard software.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var tw *timew.Report
-		var reader io.Reader
-		var err error
-		if timecardOptions.InputFile != "" {
-			file, err := os.Open(timecardOptions.InputFile)
-			if err != nil {
-				handleError(cmd, "opening input file %s: %s\n", timecardOptions.InputFile, err)
-				os.Exit(1)
-			}
-			defer file.Close()
-			reader = file
-		} else {
-			// Get Intervals from export
-			if len(args) == 0 {
-				args = append(args, ":week")
-			}
-			cli := timew.NewCLI()
-			reader, err = cli.Report(append([]string{"echo"}, args...)...)
-			if err != nil {
-				handleError(cmd, "running 'echo' report: %s\n", err)
-				os.Exit(1)
-			}
+		if timecardInteractive {
+			browseTimecard(cmd, args)
+			return
 		}
-		timecardOptions.OutputFormat = strings.ToLower(timecardOptions.OutputFormat)
-
-		// Create timewarrior report object
-		tw, err = timew.NewReport(reader)
+		tw, err := loadReport(timecardOptions.InputFile, args)
 		if err != nil {
-			handleError(cmd, "parsing 'echo' output: %s\n", err)
+			handleError(cmd, "%s", err)
+			os.Exit(1)
+		}
+		timecardOptions.Locks = reportLocks(cmd, tw.Config)
+		timecardOptions.OutputFormat = strings.ToLower(timecardOptions.OutputFormat)
+		spreadsheet := timecardOptions.OutputFormat == "xlsx" || timecardOptions.OutputFormat == "ods"
+		if _, _, ok := terminalSize(cmd); ok && spreadsheet && timecardOptions.Template == "" {
+			handleError(cmd, "refusing to write %s to a terminal; redirect the output to a file", timecardOptions.OutputFormat)
 			os.Exit(1)
 		}
+		if width, _, ok := terminalSize(cmd); ok && timecardOptions.Width == 0 {
+			timecardOptions.Width = width
+		}
 
 		// Run
 		msg, err := timecard.Run(tw, timecardOptions)
@@ -63,19 +59,20 @@ var timecardCmd = &cobra.Command{
 			handleError(cmd, "%s", err)
 			os.Exit(1)
 		}
-		fmt.Fprint(cmd.OutOrStdout(), msg)
-		fmt.Fprint(cmd.OutOrStdout(), "\n")
+		if timecardOptions.Template != "" || spreadsheet {
+			// Templates control their own trailing newline, and spreadsheets
+			// are binary
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return
+		}
+		writePaged(cmd, msg, timecardOptions.OutputFormat == "table" && !timecardNoPager)
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(timecardCmd)
-	timecardCmd.Flags().IntVar(
-		&timecardOptions.Increment,
-		"increment",
-		6,
-		"Increment up to which each duration will be rounded (in minutes)",
-	)
+	addDataFlags(timecardCmd, &timecardOptions)
+	addTemplateFlag(timecardCmd, &timecardOptions)
 	timecardCmd.Flags().BoolVar(
 		&timecardOptions.IncludeTotalRow,
 		"total-row",
@@ -92,18 +89,167 @@ func init() {
 		&timecardOptions.OutputFormat,
 		"format",
 		"table",
-		"Output format for report (options: table, csv)",
+		"Output format for report (options: table, csv, tsv, json, markdown, html, xlsx, ods)",
+	)
+	timecardCmd.Flags().StringVar(
+		&timecardOptions.Granularity,
+		"by",
+		timecard.ByDay,
+		"Period covered by each column (options: day, week, month)",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.Transpose,
+		"transpose",
+		false,
+		"Show periods as rows and tags as columns",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.HTMLStyle,
+		"html-css",
+		false,
+		"Include inline CSS in HTML output",
+	)
+	timecardCmd.Flags().StringVar(
+		&timecardOptions.Units,
+		"units",
+		timecard.UnitsDecimal,
+		"Units in which durations are displayed (options: decimal, hm, minutes, percent-of-day, percent-of-total)",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.ShowExcluded,
+		"show-excluded",
+		false,
+		"Report the time excluded by filters below the timecard",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.ShowAmounts,
+		"amounts",
+		false,
+		"Include a column and row with the amounts billed at the configured rates",
+	)
+	timecardCmd.Flags().IntVar(
+		&timecardOptions.Width,
+		"width",
+		0,
+		"Width into which the table is fitted (defaults to the terminal width)",
+	)
+	timecardCmd.Flags().BoolVarP(
+		&timecardInteractive,
+		"interactive",
+		"i",
+		false,
+		"Browse the timecard week by week in an interactive view",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardNoPager,
+		"no-pager",
+		false,
+		"Do not pipe tables taller than the terminal through $PAGER",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.WorkdaysOnly,
+		"workdays-only",
+		false,
+		"Only show weekends and holidays if time was recorded on them",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.DimDaysOff,
+		"dim-days-off",
+		false,
+		"Grey out weekends and holidays",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.ShowNotes,
+		"notes",
+		false,
+		"Collect the annotations of each tag/day as notes",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.ShowExpected,
+		"expected",
+		false,
+		"Include rows with the hours expected, recorded and the difference, and report the flex-time balance",
+	)
+	timecardCmd.Flags().Float64Var(
+		&timecardOptions.ExpectedWeekly,
+		"expected-weekly",
+		0,
+		"Hours expected each week, spread over Monday to Friday (defaults to the twe.expected.weekly setting)",
 	)
 	timecardCmd.Flags().StringVar(
-		&timecardOptions.InputFile,
-		"file",
+		&timecardOptions.Ledger,
+		"ledger",
 		"",
-		"Input file to read from. If none specified, will read from STDIN.",
+		"Ledger file from which the flex-time balance is carried (defaults to the twe.ledger setting)",
+	)
+	timecardCmd.Flags().BoolVar(
+		&timecardOptions.RecordBalance,
+		"record-balance",
+		false,
+		"Record the difference between the hours recorded and expected in the ledger",
+	)
+	timecardCmd.Flags().StringVar(
+		&timecardOptions.Sort,
+		"sort",
+		timecard.SortName,
+		"Order in which rows are sorted (options: name, total, first-seen)",
+	)
+	timecardCmd.Flags().IntVar(
+		&timecardOptions.Top,
+		"top",
+		0,
+		"Only show the top N rows by total, collapsing the rest into an \"Other\" row (0 to show all)",
 	)
-	timecardCmd.Flags().StringArrayVar(
-		&timecardOptions.Filters,
-		"filter",
-		[]string{},
-		"List of filters to apply to tags. Regular expressions are supported",
+	timecardCmd.Flags().DurationVar(
+		&timecardOptions.Min,
+		"min",
+		0,
+		"Collapse rows with a total below this (e.g. 30m) into an \"Other\" row",
 	)
 }
+
+// Run the interactive timecard browser, starting on the week in which the
+// given range starts (the current week by default). Weeks are read from
+// Timewarrior as they are browsed, unless the report was read from a file or
+// passed by Timewarrior, in which case only its intervals can be browsed.
+func browseTimecard(cmd *cobra.Command, args []string) {
+	if _, _, ok := terminalSize(cmd); !ok {
+		handleError(cmd, "the interactive timecard requires a terminal")
+		os.Exit(1)
+	}
+	tw, err := loadReport(timecardOptions.InputFile, args)
+	if err != nil {
+		handleError(cmd, "%s", err)
+		os.Exit(1)
+	}
+	timecardOptions.Locks = reportLocks(cmd, tw.Config)
+	date := time.Now()
+	if start, err := tw.GetStartDate(); err == nil {
+		date = start.Local().Time
+	}
+
+	load := func(start, end time.Time) (*timew.Report, error) {
+		return tw.Slice(start, end), nil
+	}
+	if extensionReport == nil && timecardOptions.InputFile == "" {
+		load = func(start, end time.Time) (*timew.Report, error) {
+			return loadReport("", []string{start.Format(timecard.ISODayFormat), "-", end.Format(timecard.ISODayFormat)})
+		}
+	}
+	edit := func(date time.Time) *exec.Cmd {
+		executable, err := os.Executable()
+		if err != nil {
+			executable = os.Args[0]
+		}
+		return exec.Command(executable, "edit", date.Format(timecard.ISODayFormat))
+	}
+
+	m, err := browse.NewModel(load, edit, timecardOptions, date)
+	if err != nil {
+		handleError(cmd, "initializing application: %v", err)
+		os.Exit(1)
+	}
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		handleError(cmd, "running application: %v", err)
+	}
+}
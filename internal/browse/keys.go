@@ -0,0 +1,132 @@
+package browse
+
+import "github.com/charmbracelet/bubbles/key"
+
+type keyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	PrevWeek key.Binding
+	NextWeek key.Binding
+	Today    key.Binding
+	Totals   key.Binding
+	Rounding key.Binding
+	Grouping key.Binding
+	Select   key.Binding
+	Back     key.Binding
+	Edit     key.Binding
+	Reload   key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
+// ShortHelp returns keybindings to be shown in the mini help view. It's part
+// of the key.Map interface.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.PrevWeek, k.NextWeek, k.Select, k.Edit, k.Help, k.Quit}
+}
+
+// FullHelp returns keybindings for the expanded help view. It's part of the
+// key.Map interface.
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Left, k.Right},
+		{k.PrevWeek, k.NextWeek, k.Today},
+		{k.Totals, k.Rounding, k.Grouping},
+		{k.Select, k.Back, k.Edit},
+		{k.Reload, k.Help, k.Quit},
+	}
+}
+
+// Keys of the drill-down view, which lists the intervals of a cell
+type intervalKeyMap struct {
+	Up   key.Binding
+	Down key.Binding
+	Back key.Binding
+	Edit key.Binding
+	Quit key.Binding
+}
+
+func (k intervalKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Back, k.Edit, k.Quit}
+}
+
+func (k intervalKeyMap) FullHelp() [][]key.Binding {
+	return nil
+}
+
+var keys = keyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("j", "move down"),
+	),
+	Left: key.NewBinding(
+		key.WithKeys("h", "shift+tab"),
+		key.WithHelp("h/shift+tab", "move left"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("l", "tab"),
+		key.WithHelp("l/tab", "move right"),
+	),
+	PrevWeek: key.NewBinding(
+		key.WithKeys("left", "p"),
+		key.WithHelp("←/p", "previous week"),
+	),
+	NextWeek: key.NewBinding(
+		key.WithKeys("right", "n"),
+		key.WithHelp("→/n", "next week"),
+	),
+	Today: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "this week"),
+	),
+	Totals: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "toggle totals"),
+	),
+	Rounding: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "toggle rounding"),
+	),
+	Grouping: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "toggle grouping"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "show intervals"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc", "backspace"),
+		key.WithHelp("esc", "back"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit day"),
+	),
+	Reload: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "reload"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+var intervalKeys = intervalKeyMap{
+	Up:   keys.Up,
+	Down: keys.Down,
+	Back: keys.Back,
+	Edit: keys.Edit,
+	Quit: keys.Quit,
+}
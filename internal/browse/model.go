@@ -0,0 +1,392 @@
+// Package browse provides an interactive timecard, which can be browsed week
+// by week and drilled into to show the intervals counted in each cell.
+package browse
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kgoettler/twe/internal/timecard"
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	tableFormatter "github.com/charmbracelet/lipgloss/table"
+)
+
+// Loader returns the report of the range from start (inclusive) to end
+// (exclusive).
+type Loader func(start, end time.Time) (*timew.Report, error)
+
+// Editor returns the command which edits the given day (i.e. `twe edit`).
+type Editor func(date time.Time) *exec.Cmd
+
+type Model struct {
+	load Loader
+	edit Editor
+
+	// Options of the timecard. Totals, rounding and grouping are toggled by
+	// the user.
+	options timecard.TimecardOptions
+
+	// Rounding increment restored when rounding is toggled back on
+	increment int
+
+	// First day (Monday) of the week shown
+	week time.Time
+
+	// Report of the week and the timecard generated from it
+	report *timew.Report
+	data   timecard.TimecardData
+	view   timecard.TemplateData
+
+	// Cell under the cursor, indexed as in TimecardData.CellIntervals
+	row, col int
+
+	// Intervals of the cell drilled into, and the one under the cursor. Nil
+	// unless a cell has been drilled into.
+	intervals []timecard.TemplateInterval
+	interval  int
+
+	help help.Model
+
+	// Message to display below the table
+	message string
+}
+
+// NewModel returns a timecard browser starting on the week which contains
+// date. Weeks are loaded with load, and days are edited with the command
+// returned by edit (editing is disabled if it is nil).
+func NewModel(load Loader, edit Editor, options timecard.TimecardOptions, date time.Time) (Model, error) {
+	// Browsing is done one week of days at a time, on a single table
+	options.Granularity = timecard.ByDay
+	options.Transpose = false
+	options.Width = 0
+	m := Model{
+		load:      load,
+		edit:      edit,
+		options:   options,
+		increment: options.Increment,
+		week:      startOfWeek(date),
+		help:      help.New(),
+	}
+	if err := m.loadWeek(); err != nil {
+		return Model{}, err
+	}
+	return m, nil
+}
+
+// Returns the Monday of the week which contains date.
+func startOfWeek(date time.Time) time.Time {
+	y, mo, d := date.Date()
+	day := time.Date(y, mo, d, 0, 0, 0, 0, date.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// Load the report of the current week and regenerate the timecard.
+func (m *Model) loadWeek() error {
+	report, err := m.load(m.week, m.week.AddDate(0, 0, 7))
+	if err != nil {
+		return fmt.Errorf("loading week of %s: %w", m.week.Format(timecard.ISODayFormat), err)
+	}
+	m.report = report
+	return m.refresh()
+}
+
+// Regenerate the timecard from the loaded report, e.g. after an option has
+// been toggled.
+func (m *Model) refresh() error {
+	data, err := timecard.NewTimecardData(m.report, m.options)
+	if err != nil {
+		return err
+	}
+	m.data = data
+	m.view = data.TemplateData()
+	m.row = max(min(m.row, m.rows()-1), 0)
+	m.col = max(min(m.col, m.cols()-1), 0)
+	return nil
+}
+
+// Returns the number of rows the cursor can move over, including the total
+// row.
+func (m Model) rows() int {
+	if m.options.IncludeTotalRow && len(m.view.Rows) > 0 {
+		return len(m.view.Rows) + 1
+	}
+	return len(m.view.Rows)
+}
+
+// Returns the number of columns the cursor can move over, including the
+// total column.
+func (m Model) cols() int {
+	if m.options.IncludeTotalCol {
+		return len(m.view.Columns) + 1
+	}
+	return len(m.view.Columns)
+}
+
+// Returns the day of the column under the cursor, or false if the cursor is
+// on the total column.
+func (m Model) day() (time.Time, bool) {
+	if m.col >= len(m.view.Columns) {
+		return time.Time{}, false
+	}
+	return m.view.Columns[m.col], true
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+		return m, nil
+	case msgEdited:
+		if msg.err != nil {
+			return m.setError(fmt.Errorf("editing: %w", msg.err))
+		}
+		// Pick up the changes made in the editor
+		m.intervals = nil
+		return m.setError(m.loadWeek())
+	case msgClear:
+		m.message = ""
+		return m, nil
+	case tea.KeyMsg:
+		if m.intervals != nil {
+			return m.handleIntervals(msg)
+		}
+		return m.handleTable(msg)
+	}
+	return m, nil
+}
+
+// Update function called when the timecard is shown
+func (m Model) handleTable(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.row = max(m.row-1, 0)
+	case key.Matches(msg, keys.Down):
+		m.row = max(min(m.row+1, m.rows()-1), 0)
+	case key.Matches(msg, keys.Left):
+		m.col = max(m.col-1, 0)
+	case key.Matches(msg, keys.Right):
+		m.col = max(min(m.col+1, m.cols()-1), 0)
+	case key.Matches(msg, keys.PrevWeek):
+		return m.moveWeek(m.week.AddDate(0, 0, -7))
+	case key.Matches(msg, keys.NextWeek):
+		return m.moveWeek(m.week.AddDate(0, 0, 7))
+	case key.Matches(msg, keys.Today):
+		return m.moveWeek(startOfWeek(time.Now()))
+	case key.Matches(msg, keys.Totals):
+		totals := !(m.options.IncludeTotalRow && m.options.IncludeTotalCol)
+		m.options.IncludeTotalRow, m.options.IncludeTotalCol = totals, totals
+		return m.setError(m.refresh())
+	case key.Matches(msg, keys.Rounding):
+		if m.increment == 0 {
+			return m.setError(fmt.Errorf("no rounding increment set (see --increment)"))
+		}
+		if m.options.Increment > 0 {
+			m.options.Increment = 0
+		} else {
+			m.options.Increment = m.increment
+		}
+		return m.setError(m.refresh())
+	case key.Matches(msg, keys.Grouping):
+		m.options.Ungrouped = !m.options.Ungrouped
+		return m.setError(m.refresh())
+	case key.Matches(msg, keys.Select):
+		if m.rows() > 0 && m.cols() > 0 {
+			m.intervals = m.data.CellIntervals(m.row, m.col)
+			m.interval = 0
+		}
+	case key.Matches(msg, keys.Edit):
+		day, ok := m.day()
+		if !ok {
+			return m.setError(fmt.Errorf("move to a day to edit it"))
+		}
+		return m.editDay(day)
+	case key.Matches(msg, keys.Reload):
+		return m.setError(m.loadWeek())
+	case key.Matches(msg, keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+	case key.Matches(msg, keys.Quit):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// Update function called when the intervals of a cell are shown
+func (m Model) handleIntervals(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, intervalKeys.Up):
+		m.interval = max(m.interval-1, 0)
+	case key.Matches(msg, intervalKeys.Down):
+		m.interval = max(min(m.interval+1, len(m.intervals)-1), 0)
+	case key.Matches(msg, intervalKeys.Back):
+		m.intervals = nil
+	case key.Matches(msg, intervalKeys.Edit):
+		// Edit the day the selected interval starts on
+		if len(m.intervals) > 0 {
+			return m.editDay(m.intervals[m.interval].Start)
+		}
+		if day, ok := m.day(); ok {
+			return m.editDay(day)
+		}
+	case key.Matches(msg, intervalKeys.Quit):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// Show the given week.
+func (m Model) moveWeek(week time.Time) (Model, tea.Cmd) {
+	previous := m.week
+	m.week = week
+	if err := m.loadWeek(); err != nil {
+		m.week = previous
+		return m.setError(err)
+	}
+	return m, nil
+}
+
+// Suspend the browser and edit the given day. The week is reloaded once the
+// editor exits.
+func (m Model) editDay(day time.Time) (Model, tea.Cmd) {
+	if m.edit == nil {
+		return m.setError(fmt.Errorf("editing is not available"))
+	}
+	return m, tea.ExecProcess(m.edit(day), func(err error) tea.Msg {
+		return msgEdited{err}
+	})
+}
+
+func (m Model) setError(err error) (Model, tea.Cmd) {
+	if err == nil {
+		m.message = ""
+		return m, nil
+	}
+	m.message = err.Error()
+	return m, clearMessage()
+}
+
+func (m Model) View() string {
+	var title, body string
+	var helpString string
+	if m.intervals != nil {
+		title = m.cellTitle()
+		body = m.intervalTable()
+		helpString = m.help.View(intervalKeys)
+	} else {
+		title = "Week of " + m.week.Format("Mon 02-Jan-2006")
+		body = m.data.StringTableCursor(m.row, m.col)
+		helpString = m.help.View(keys)
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		TitleStyle.Render(title),
+		StatusStyle.Render(m.status()),
+		body,
+		ErrStyle.Render(m.message),
+		lipgloss.NewStyle().PaddingLeft(1).Render(helpString),
+	)
+}
+
+// Returns a line describing the toggled options.
+func (m Model) status() string {
+	onOff := func(b bool) string {
+		if b {
+			return "on"
+		}
+		return "off"
+	}
+	rounding := "off"
+	if m.options.Increment > 0 {
+		rounding = fmt.Sprintf("%d min", m.options.Increment)
+	}
+	return fmt.Sprintf("totals: %s · rounding: %s · grouping: %s",
+		onOff(m.options.IncludeTotalRow || m.options.IncludeTotalCol),
+		rounding,
+		onOff(!m.options.Ungrouped))
+}
+
+// Returns the title of the drill-down view, naming the row and day of the
+// cell.
+func (m Model) cellTitle() string {
+	row := "TOTAL"
+	if m.row < len(m.view.Rows) {
+		row = strings.Join(m.view.Rows[m.row].Path, " / ")
+	}
+	day := "whole week"
+	if d, ok := m.day(); ok {
+		day = d.Format(timecard.DayFormat)
+	}
+	return fmt.Sprintf("%s · %s", row, day)
+}
+
+// Returns the intervals of the cell drilled into as a table, with the one
+// under the cursor highlighted.
+func (m Model) intervalTable() string {
+	if len(m.intervals) == 0 {
+		return PlaceholderStyle.Render("No intervals recorded")
+	}
+	rows := make([][]string, len(m.intervals))
+	for i, interval := range m.intervals {
+		end := interval.End.Format("15:04")
+		if interval.Running {
+			end += " (running)"
+		}
+		rows[i] = []string{
+			fmt.Sprintf("@%d", interval.ID),
+			interval.Start.Format("Mon 01/02"),
+			interval.Start.Format("15:04"),
+			end,
+			formatHM(interval.Duration),
+			strings.Join(interval.Tags, ", "),
+			interval.Annotation,
+		}
+	}
+	return tableFormatter.New().
+		Headers("ID", "Day", "Start", "End", "Duration", "Tags", "Annotation").
+		Rows(rows...).
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(TableBorderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch row {
+			case tableFormatter.HeaderRow:
+				return HeaderStyle
+			case m.interval:
+				return CursorStyle
+			default:
+				return CellStyle
+			}
+		}).
+		Render()
+}
+
+// Format a duration as hours and minutes (e.g. 7:45).
+func formatHM(d time.Duration) string {
+	m := int64(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%d:%02d", m/60, m%60)
+}
+
+// Commands + messages
+
+// msgEdited is sent when the editor exits.
+type msgEdited struct {
+	err error
+}
+
+// msgClear clears the message below the table.
+type msgClear struct{}
+
+func clearMessage() tea.Cmd {
+	return tea.Tick(7*time.Second, func(time.Time) tea.Msg {
+		return msgClear{}
+	})
+}
@@ -0,0 +1,161 @@
+package browse
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kgoettler/twe/internal/timecard"
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/suite"
+)
+
+type ModelTestSuite struct {
+	suite.Suite
+	report *timew.Report
+
+	// Ranges requested from the loader
+	loaded []time.Time
+}
+
+func TestModelTestSuite(t *testing.T) {
+	suite.Run(t, new(ModelTestSuite))
+}
+
+func (suite *ModelTestSuite) SetupTest() {
+	report, err := timew.NewReport(strings.NewReader(`twe.group.Clients: ^acme-.*
+
+[
+{"id":4,"start":"20260105T140000Z","end":"20260105T150000Z","tags":["Admin"]},
+{"id":3,"start":"20260106T140000Z","end":"20260106T160700Z","tags":["acme-web"],"annotation":"deploy"},
+{"id":2,"start":"20260106T160700Z","end":"20260106T170000Z","tags":["acme-api"]},
+{"id":1,"start":"20260113T140000Z","end":"20260113T150000Z","tags":["Admin"]}
+]`))
+	suite.Require().NoError(err)
+	suite.report = report
+	suite.loaded = nil
+}
+
+func (suite *ModelTestSuite) newModel(options timecard.TimecardOptions) Model {
+	load := func(start, end time.Time) (*timew.Report, error) {
+		suite.loaded = append(suite.loaded, start, end)
+		return suite.report.Slice(start, end), nil
+	}
+	edit := func(date time.Time) *exec.Cmd {
+		return exec.Command("true", date.Format(timecard.ISODayFormat))
+	}
+	m, err := NewModel(load, edit, options, time.Date(2026, 1, 7, 12, 0, 0, 0, time.Local))
+	suite.Require().NoError(err)
+	return m
+}
+
+// Send keys to the model and return the updated model and the last command.
+func press(m Model, keys ...tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var next tea.Model
+		next, cmd = m.Update(k)
+		m = next.(Model)
+	}
+	return m, cmd
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func (suite *ModelTestSuite) TestNewModel() {
+	m := suite.newModel(timecard.TimecardOptions{Transpose: true})
+	suite.Equal(time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local), m.week)
+	suite.Equal([]time.Time{m.week, m.week.AddDate(0, 0, 7)}, suite.loaded)
+	suite.Equal(timecard.ByDay, m.options.Granularity)
+	suite.False(m.options.Transpose)
+	suite.Len(m.view.Columns, 7)
+	suite.Equal("Clients", m.view.Rows[1].Tag)
+	suite.Contains(m.View(), "Week of Mon 05-Jan-2026")
+}
+
+func (suite *ModelTestSuite) TestWeeks() {
+	m := suite.newModel(timecard.TimecardOptions{})
+	m, _ = press(m, tea.KeyMsg{Type: tea.KeyRight})
+	suite.Equal(time.Date(2026, 1, 12, 0, 0, 0, 0, time.Local), m.week)
+	suite.Require().Len(m.view.Rows, 1)
+	suite.Equal("Admin", m.view.Rows[0].Tag)
+
+	m, _ = press(m, tea.KeyMsg{Type: tea.KeyLeft}, runes("p"))
+	suite.Equal(time.Date(2025, 12, 29, 0, 0, 0, 0, time.Local), m.week)
+	suite.Empty(m.view.Rows)
+}
+
+func (suite *ModelTestSuite) TestToggles() {
+	m := suite.newModel(timecard.TimecardOptions{Increment: 15})
+
+	m, _ = press(m, runes("t"))
+	suite.True(m.options.IncludeTotalRow)
+	suite.True(m.options.IncludeTotalCol)
+	m, _ = press(m, runes("j"), runes("j"), runes("j"), tea.KeyMsg{Type: tea.KeyTab})
+	suite.Equal(2, m.row, "cursor stops on the total row")
+	suite.Equal(1, m.col)
+
+	// Rounding up to 15 minutes
+	suite.Equal(3*time.Hour+15*time.Minute, m.view.Rows[1].Total)
+	m, _ = press(m, runes("r"))
+	suite.Equal(0, m.options.Increment)
+	suite.Equal(3*time.Hour, m.view.Rows[1].Total)
+	m, _ = press(m, runes("r"))
+	suite.Equal(15, m.options.Increment)
+
+	m, _ = press(m, runes("g"))
+	suite.True(m.options.Ungrouped)
+	suite.Len(m.view.Rows, 3)
+	suite.Equal("acme-api", m.view.Rows[1].Tag)
+	suite.Contains(m.View(), "grouping: off")
+
+	m, _ = press(m, runes("t"))
+	suite.False(m.options.IncludeTotalRow)
+	suite.Equal(2, m.row)
+}
+
+func (suite *ModelTestSuite) TestDrillDown() {
+	m := suite.newModel(timecard.TimecardOptions{})
+	m, _ = press(m, runes("j"), runes("l"), tea.KeyMsg{Type: tea.KeyEnter})
+	suite.Require().Len(m.intervals, 2)
+	suite.Equal(3, m.intervals[0].ID)
+	suite.Equal("deploy", m.intervals[0].Annotation)
+	suite.Contains(m.View(), "Clients · Tue 01/06")
+
+	m, _ = press(m, runes("j"))
+	suite.Equal(1, m.interval)
+	_, cmd := press(m, runes("e"))
+	suite.NotNil(cmd)
+
+	m, _ = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	suite.Nil(m.intervals)
+
+	// Empty cells can be drilled into too
+	m, _ = press(m, runes("h"), runes("k"), runes("l"), tea.KeyMsg{Type: tea.KeyEnter})
+	suite.NotNil(m.intervals)
+	suite.Empty(m.intervals)
+}
+
+func (suite *ModelTestSuite) TestEdit() {
+	m := suite.newModel(timecard.TimecardOptions{IncludeTotalCol: true})
+	_, cmd := press(m, runes("e"))
+	suite.NotNil(cmd)
+
+	// The total column is not a day
+	m, _ = press(m, runes("l"), runes("l"), runes("l"), runes("l"), runes("l"), runes("l"), runes("l"))
+	suite.Equal(7, m.col)
+	m, _ = press(m, runes("e"))
+	suite.Equal("move to a day to edit it", m.message)
+
+	// The week is reloaded once the editor exits
+	suite.loaded = nil
+	next, _ := m.Update(msgEdited{})
+	m = next.(Model)
+	suite.Len(suite.loaded, 2)
+	suite.Empty(m.message)
+}
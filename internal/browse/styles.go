@@ -0,0 +1,20 @@
+package browse
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	styles "github.com/kgoettler/twe/internal/styles"
+)
+
+var (
+	TitleStyle       = lipgloss.NewStyle().Foreground(styles.ColorPrimaryText).Bold(true).PaddingLeft(1)
+	StatusStyle      = lipgloss.NewStyle().Foreground(styles.ColorMutedText).PaddingLeft(1)
+	ErrStyle         = lipgloss.NewStyle().Foreground(styles.ColorError)
+	PlaceholderStyle = lipgloss.NewStyle().Foreground(styles.ColorMutedText).PaddingLeft(1)
+
+	// Table styles
+	TableBorderStyle = styles.BorderStyle
+	HeaderStyle      = styles.HeaderStyle
+	CellStyle        = styles.BaseStyle
+	CursorStyle      = styles.CursorStyle
+)
@@ -0,0 +1,194 @@
+package lock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// Layout of the times passed to `timew modify` and `timew stop` (see
+// timew.Datetime.LocalString)
+const localLayout = "20060102T150405"
+
+// Backend is the interface through which intervals are changed in
+// Timewarrior (e.g. timew.CLI).
+type Backend interface {
+	Annotate(id int, annotation string) error
+	Delete(id int) error
+	Export(args ...string) ([]timew.Interval, error)
+	Modify(id int, field string, value string) error
+	Retag(id int, tags []string) error
+	Stop(stopTime *string) error
+	Track(interval timew.Interval) error
+	Undo() error
+}
+
+// LockedError is returned when a change would touch an interval in a locked
+// period.
+type LockedError struct {
+	Locks []Lock
+}
+
+func (e *LockedError) Error() string {
+	periods := make([]string, len(e.Locks))
+	for i, l := range e.Locks {
+		periods[i] = l.String()
+	}
+	return fmt.Sprintf("period %s is locked (unlock it with `twe unlock` or use --force)", strings.Join(periods, ", "))
+}
+
+// Guard is a Backend which refuses to change intervals in locked periods,
+// unless forced. Changes are checked against both the interval as it is and
+// as it would be after the change. The change reverted by Undo is not known,
+// so Undo is checked against the range set by SetUndoRange (e.g. the day
+// being edited), and refused whenever there are locks if no range is set.
+type Guard struct {
+	Backend
+	locks *Locks
+	force bool
+
+	// Range which Undo is assumed to change (zero if not set)
+	undoStart time.Time
+	undoEnd   time.Time
+}
+
+// NewGuard returns a Guard which changes intervals through backend. If force
+// is set, locks are ignored.
+func NewGuard(backend Backend, locks *Locks, force bool) *Guard {
+	return &Guard{Backend: backend, locks: locks, force: force}
+}
+
+// Returns a LockedError if the range from start to end (zero if the range has
+// not ended) overlaps a locked period.
+func (g *Guard) check(start, end time.Time) error {
+	if g.force {
+		return nil
+	}
+	if locks := g.locks.Overlapping(start, end); len(locks) > 0 {
+		return &LockedError{Locks: locks}
+	}
+	return nil
+}
+
+// Returns a LockedError if the interval overlaps a locked period.
+func (g *Guard) checkInterval(interval timew.Interval) error {
+	var end time.Time
+	if interval.End != nil {
+		end = interval.End.Time
+	}
+	return g.check(interval.Start.Time, end)
+}
+
+// Returns a LockedError if the interval with the given ID overlaps a locked
+// period.
+func (g *Guard) checkID(id int) (timew.Interval, error) {
+	if g.force {
+		return timew.Interval{}, nil
+	}
+	intervals, err := g.Backend.Export(fmt.Sprintf("@%d", id))
+	if err != nil {
+		return timew.Interval{}, fmt.Errorf("reading interval @%d: %w", id, err)
+	}
+	if len(intervals) == 0 {
+		return timew.Interval{}, fmt.Errorf("interval @%d not found", id)
+	}
+	return intervals[0], g.checkInterval(intervals[0])
+}
+
+func (g *Guard) Annotate(id int, annotation string) error {
+	if _, err := g.checkID(id); err != nil {
+		return err
+	}
+	return g.Backend.Annotate(id, annotation)
+}
+
+func (g *Guard) Delete(id int) error {
+	if _, err := g.checkID(id); err != nil {
+		return err
+	}
+	return g.Backend.Delete(id)
+}
+
+func (g *Guard) Retag(id int, tags []string) error {
+	if _, err := g.checkID(id); err != nil {
+		return err
+	}
+	return g.Backend.Retag(id, tags)
+}
+
+// Modify checks the interval both before and after its start or end is moved.
+func (g *Guard) Modify(id int, field string, value string) error {
+	interval, err := g.checkID(id)
+	if err != nil {
+		return err
+	}
+	if !g.force {
+		t, err := time.ParseInLocation(localLayout, value, time.Local)
+		if err != nil {
+			return fmt.Errorf("parsing %s time %s: %w", field, value, err)
+		}
+		if field == "start" {
+			interval.Start = &timew.Datetime{Time: t}
+		} else {
+			interval.End = &timew.Datetime{Time: t}
+		}
+		if err := g.checkInterval(interval); err != nil {
+			return err
+		}
+	}
+	return g.Backend.Modify(id, field, value)
+}
+
+// Stop checks the running interval, which ends at the given time (now if it
+// is nil).
+func (g *Guard) Stop(stopTime *string) error {
+	if !g.force {
+		intervals, err := g.Backend.Export("@1")
+		if err != nil {
+			return fmt.Errorf("reading running interval: %w", err)
+		}
+		if len(intervals) > 0 && intervals[0].IsOpen() {
+			end := time.Now()
+			if stopTime != nil {
+				end, err = time.ParseInLocation(localLayout, *stopTime, time.Local)
+				if err != nil {
+					return fmt.Errorf("parsing stop time %s: %w", *stopTime, err)
+				}
+			}
+			if err := g.check(intervals[0].Start.Time, end); err != nil {
+				return err
+			}
+		}
+	}
+	return g.Backend.Stop(stopTime)
+}
+
+// SetUndoRange sets the range from start (inclusive) to end (exclusive)
+// which the changes reverted by Undo are assumed to fall in.
+func (g *Guard) SetUndoRange(start, end time.Time) {
+	g.undoStart, g.undoEnd = start, end
+}
+
+// Undo checks the range set by SetUndoRange, or refuses if there are any
+// locks and no range is set.
+func (g *Guard) Undo() error {
+	if !g.force {
+		if g.undoStart.IsZero() {
+			if len(g.locks.Entries) > 0 {
+				return &LockedError{Locks: g.locks.Entries}
+			}
+		} else if err := g.check(g.undoStart, g.undoEnd); err != nil {
+			return err
+		}
+	}
+	return g.Backend.Undo()
+}
+
+func (g *Guard) Track(interval timew.Interval) error {
+	if err := g.checkInterval(interval); err != nil {
+		return err
+	}
+	return g.Backend.Track(interval)
+}
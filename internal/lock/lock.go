@@ -0,0 +1,199 @@
+// Package lock records locked periods, i.e. periods whose timesheets have
+// been submitted, so that the intervals in them are not changed by accident.
+//
+// Locked periods are stored one per line in a lock file:
+//
+//	<start> <end> <locked>
+//
+// where start (inclusive) and end (exclusive) are dates formatted as
+// YYYY-MM-DD, and locked is the date the period was locked on. The lock file
+// is set with the `twe.locks` setting in timewarrior.cfg, and defaults to
+// `twe.locks` in the Timewarrior database directory.
+package lock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config is the timewarrior.cfg setting which defines the path of the lock
+// file (e.g. `twe.locks = ~/.timewarrior/submitted.locks`).
+const Config = "twe.locks"
+
+// Name of the lock file in the Timewarrior database directory, used if
+// `twe.locks` is not set
+const DefaultName = "twe.locks"
+
+const dateLayout = "2006-01-02"
+
+// Lock is a single locked period.
+type Lock struct {
+	Start time.Time
+	End   time.Time
+
+	// Date the period was locked on
+	Locked time.Time
+}
+
+// Returns true if the lock overlaps the range from start (inclusive) to end
+// (exclusive). A zero end stands for a range which has not ended yet.
+func (l Lock) Overlaps(start, end time.Time) bool {
+	return start.Before(l.End) && (end.IsZero() || end.After(l.Start))
+}
+
+// Returns the period as dates, with the last day included (e.g. 2026-01-05 to
+// 2026-01-11).
+func (l Lock) String() string {
+	return fmt.Sprintf("%s to %s", l.Start.Format(dateLayout), l.End.AddDate(0, 0, -1).Format(dateLayout))
+}
+
+// Locks contains the locked periods, sorted by start.
+type Locks struct {
+	Entries []Lock
+}
+
+// Path returns the path of the lock file, given the `twe.locks` setting and
+// the Timewarrior database directory. Either may be empty: the database
+// directory defaults to $TIMEWARRIORDB, or ~/.timewarrior if it is not set.
+func Path(setting, db string) string {
+	if setting != "" {
+		return expandHome(setting)
+	}
+	if db == "" {
+		db = os.Getenv("TIMEWARRIORDB")
+	}
+	if db == "" {
+		db = "~/.timewarrior"
+	}
+	return filepath.Join(expandHome(db), DefaultName)
+}
+
+// Read reads a lock file. A file which does not exist yet is read as having
+// no locks.
+func Read(path string) (*Locks, error) {
+	l := &Locks{}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected '<start> <end> <locked>'", path, n)
+		}
+		dates := make([]time.Time, len(fields))
+		for i, field := range fields {
+			dates[i], err = time.ParseInLocation(dateLayout, field, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid date %s", path, n, field)
+			}
+		}
+		l.Entries = append(l.Entries, Lock{Start: dates[0], End: dates[1], Locked: dates[2]})
+	}
+	return l, scanner.Err()
+}
+
+// Lock the period from start (inclusive) to end (exclusive), widened to whole
+// days. The period replaces any locks it overlaps, so they are merged.
+func (l *Locks) Lock(start, end, now time.Time) Lock {
+	lock := Lock{Start: day(start), End: day(end), Locked: day(now)}
+	if lock.End.Before(end) {
+		lock.End = lock.End.AddDate(0, 0, 1)
+	}
+	l.Entries = slices.DeleteFunc(l.Entries, func(e Lock) bool {
+		if !e.Overlaps(lock.Start, lock.End) {
+			return false
+		}
+		lock.Start = minTime(lock.Start, e.Start)
+		lock.End = maxTime(lock.End, e.End)
+		return true
+	})
+	l.Entries = append(l.Entries, lock)
+	slices.SortFunc(l.Entries, func(a, b Lock) int { return a.Start.Compare(b.Start) })
+	return lock
+}
+
+// Unlock the locks which overlap the range from start (inclusive) to end
+// (exclusive), and return them.
+func (l *Locks) Unlock(start, end time.Time) []Lock {
+	removed := []Lock{}
+	l.Entries = slices.DeleteFunc(l.Entries, func(e Lock) bool {
+		if e.Overlaps(start, end) {
+			removed = append(removed, e)
+			return true
+		}
+		return false
+	})
+	return removed
+}
+
+// Overlapping returns the locks which overlap the range from start
+// (inclusive) to end (exclusive). A zero end stands for a range which has not
+// ended yet.
+func (l *Locks) Overlapping(start, end time.Time) []Lock {
+	out := []Lock{}
+	for _, e := range l.Entries {
+		if e.Overlaps(start, end) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Write the locks to a file.
+func (l *Locks) Write(path string) error {
+	var builder strings.Builder
+	for _, e := range l.Entries {
+		fmt.Fprintf(&builder, "%s %s %s\n", e.Start.Format(dateLayout), e.End.Format(dateLayout), e.Locked.Format(dateLayout))
+	}
+	return os.WriteFile(path, []byte(builder.String()), 0o644)
+}
+
+// Returns the start of the day containing t, in local time.
+func day(t time.Time) time.Time {
+	y, m, d := t.Local().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Replace a leading `~/` in a path with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
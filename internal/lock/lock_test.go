@@ -0,0 +1,197 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LockSuite struct {
+	suite.Suite
+}
+
+func TestLockSuite(t *testing.T) {
+	suite.Run(t, new(LockSuite))
+}
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (suite *LockSuite) TestLockUnlock() {
+	locks := &Locks{}
+	now := date("2026-01-12").Add(17 * time.Hour)
+
+	// Periods are widened to whole days
+	l := locks.Lock(date("2026-01-05").Add(9*time.Hour), date("2026-01-11").Add(time.Hour), now)
+	suite.Equal(Lock{date("2026-01-05"), date("2026-01-12"), date("2026-01-12")}, l)
+	suite.Equal("2026-01-05 to 2026-01-11", l.String())
+
+	// Overlapping locks are merged
+	locks.Lock(date("2026-01-19"), date("2026-01-26"), now)
+	locks.Lock(date("2026-01-10"), date("2026-01-13"), now)
+	suite.Equal([]Lock{
+		{date("2026-01-05"), date("2026-01-13"), date("2026-01-12")},
+		{date("2026-01-19"), date("2026-01-26"), date("2026-01-12")},
+	}, locks.Entries)
+
+	suite.Len(locks.Overlapping(date("2026-01-13"), date("2026-01-19")), 0)
+	suite.Len(locks.Overlapping(date("2026-01-12"), date("2026-01-20")), 2)
+	suite.Len(locks.Overlapping(date("2026-01-25"), time.Time{}), 1)
+
+	removed := locks.Unlock(date("2026-01-20"), date("2026-01-21"))
+	suite.Equal([]Lock{{date("2026-01-19"), date("2026-01-26"), date("2026-01-12")}}, removed)
+	suite.Len(locks.Entries, 1)
+}
+
+func (suite *LockSuite) TestReadWrite() {
+	path := filepath.Join(suite.T().TempDir(), "twe.locks")
+	locks, err := Read(path)
+	suite.Require().NoError(err)
+	suite.Empty(locks.Entries)
+
+	locks.Lock(date("2026-01-05"), date("2026-01-12"), date("2026-01-12"))
+	suite.Require().NoError(locks.Write(path))
+	content, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+	suite.Equal("2026-01-05 2026-01-12 2026-01-12\n", string(content))
+
+	read, err := Read(path)
+	suite.Require().NoError(err)
+	suite.Equal(locks.Entries, read.Entries)
+
+	suite.Require().NoError(os.WriteFile(path, []byte("# comment\n2026-01-05 2026-01-12\n"), 0o644))
+	_, err = Read(path)
+	suite.ErrorContains(err, ":2: expected")
+}
+
+func (suite *LockSuite) TestPath() {
+	suite.Equal("/tmp/submitted.locks", Path("/tmp/submitted.locks", "/db"))
+	suite.Equal(filepath.Join("/db", DefaultName), Path("", "/db"))
+	suite.T().Setenv("TIMEWARRIORDB", "/timew")
+	suite.Equal(filepath.Join("/timew", DefaultName), Path("", ""))
+}
+
+// Backend which records the changes made through it
+type fakeBackend struct {
+	intervals map[int]timew.Interval
+	calls     []string
+}
+
+func (b *fakeBackend) Annotate(id int, annotation string) error {
+	b.calls = append(b.calls, fmt.Sprintf("annotate @%d", id))
+	return nil
+}
+
+func (b *fakeBackend) Delete(id int) error {
+	b.calls = append(b.calls, fmt.Sprintf("delete @%d", id))
+	return nil
+}
+
+func (b *fakeBackend) Export(args ...string) ([]timew.Interval, error) {
+	var id int
+	if _, err := fmt.Sscanf(args[0], "@%d", &id); err != nil {
+		return nil, err
+	}
+	if interval, ok := b.intervals[id]; ok {
+		return []timew.Interval{interval}, nil
+	}
+	return []timew.Interval{}, nil
+}
+
+func (b *fakeBackend) Modify(id int, field string, value string) error {
+	b.calls = append(b.calls, fmt.Sprintf("modify %s @%d", field, id))
+	return nil
+}
+
+func (b *fakeBackend) Retag(id int, tags []string) error {
+	b.calls = append(b.calls, fmt.Sprintf("retag @%d", id))
+	return nil
+}
+
+func (b *fakeBackend) Stop(stopTime *string) error {
+	b.calls = append(b.calls, "stop")
+	return nil
+}
+
+func (b *fakeBackend) Track(interval timew.Interval) error {
+	b.calls = append(b.calls, "track")
+	return nil
+}
+
+func (b *fakeBackend) Undo() error {
+	b.calls = append(b.calls, "undo")
+	return nil
+}
+
+func interval(id int, start, end string) timew.Interval {
+	parse := func(s string) *timew.Datetime {
+		t, err := time.ParseInLocation(localLayout, s, time.Local)
+		if err != nil {
+			panic(err)
+		}
+		return &timew.Datetime{Time: t}
+	}
+	i := timew.Interval{ID: id, Start: parse(start), Tags: []string{"Work"}}
+	if end != "" {
+		i.End = parse(end)
+	}
+	return i
+}
+
+func (suite *LockSuite) TestGuard() {
+	backend := &fakeBackend{intervals: map[int]timew.Interval{
+		1: interval(1, "20260112T090000", ""),
+		2: interval(2, "20260109T090000", "20260109T170000"),
+	}}
+	locks := &Locks{}
+	locks.Lock(date("2026-01-05"), date("2026-01-12"), date("2026-01-12"))
+	guard := NewGuard(backend, locks, false)
+
+	var locked *LockedError
+	suite.True(errors.As(guard.Delete(2), &locked))
+	suite.Equal(locks.Entries, locked.Locks)
+	suite.ErrorContains(guard.Annotate(2, "note"), "period 2026-01-05 to 2026-01-11 is locked")
+	suite.ErrorAs(guard.Retag(2, []string{"Admin"}), &locked)
+	suite.ErrorAs(guard.Track(interval(0, "20260111T230000", "20260112T010000")), &locked)
+	suite.NoError(guard.Track(interval(0, "20260112T010000", "20260112T020000")))
+
+	// Intervals can't be moved into a locked period
+	suite.ErrorAs(guard.Modify(1, "start", "20260111T220000"), &locked)
+	suite.NoError(guard.Modify(1, "start", "20260112T080000"))
+	suite.NoError(guard.Stop(nil))
+
+	// Undo is refused unless the range it changes is known to be unlocked
+	suite.ErrorAs(guard.Undo(), &locked)
+	guard.SetUndoRange(date("2026-01-11"), date("2026-01-12"))
+	suite.ErrorAs(guard.Undo(), &locked)
+	guard.SetUndoRange(date("2026-01-12"), date("2026-01-13"))
+	suite.NoError(guard.Undo())
+	suite.Equal([]string{"track", "modify start @1", "stop", "undo"}, backend.calls)
+
+	// The running interval started in the locked period
+	backend.intervals[1] = interval(1, "20260111T230000", "")
+	suite.ErrorAs(guard.Stop(nil), &locked)
+
+	backend.calls = nil
+	forced := NewGuard(backend, locks, true)
+	suite.NoError(forced.Delete(2))
+	suite.NoError(forced.Modify(2, "end", "20260109T180000"))
+	suite.NoError(forced.Stop(nil))
+	suite.NoError(forced.Undo())
+	suite.Equal([]string{"delete @2", "modify end @2", "stop", "undo"}, backend.calls)
+
+	// Without locks, Undo is passed through
+	suite.NoError(NewGuard(backend, &Locks{}, false).Undo())
+}
@@ -0,0 +1,248 @@
+// Package period resolves named reporting periods (e.g. semi-monthly payroll
+// periods or 4-4-5 fiscal months) into date ranges.
+//
+// Periods are defined in timewarrior.cfg using the `twe.period.<name>` setting:
+//
+//	twe.period.payroll = semimonthly
+//	twe.period.sprint  = biweekly 2026-01-05
+//	twe.period.client  = 4-4-5 2025-12-29
+//
+// and referenced as `<name>`, `<name>-N` (N periods ago) or `<name>+N` (N
+// periods from now).
+package period
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigPrefix is the prefix of the timewarrior.cfg settings which define
+// named periods.
+const ConfigPrefix = "twe.period."
+
+const (
+	KindWeekly      = "weekly"
+	KindBiweekly    = "biweekly"
+	KindSemimonthly = "semimonthly"
+	KindMonthly     = "monthly"
+	KindFiscal      = "fiscal"
+)
+
+const (
+	anchorLayout = "2006-01-02"
+
+	// Days in a fiscal year of four 13-week quarters
+	fiscalYear = 52 * 7
+)
+
+var (
+	fiscalPattern    = regexp.MustCompile(`^(\d)-(\d)-(\d)$`)
+	referencePattern = regexp.MustCompile(`^(.+?)([+-]\d+)?$`)
+)
+
+// Period is a named, repeating reporting period.
+type Period struct {
+	Name string
+	Kind string
+
+	// First day of a period. Required for biweekly and fiscal periods,
+	// optional for weekly periods (defaults to a Monday).
+	anchor time.Time
+
+	// Number of weeks in each fiscal month of a quarter (e.g. 4-4-5)
+	weeks [3]int
+}
+
+// Parse a period definition. Supported specs are:
+//
+//	weekly [anchor]
+//	biweekly <anchor>
+//	semimonthly
+//	monthly
+//	4-4-5 <anchor> (or 4-5-4, 5-4-4)
+//
+// where anchor is the first day of any period, formatted as YYYY-MM-DD.
+// Fiscal years always have 52 weeks (there are no 53-week years), so fiscal
+// periods drift against the calendar by about one day a year.
+func Parse(name, spec string) (Period, error) {
+	fields := strings.Fields(strings.ToLower(spec))
+	if len(fields) == 0 {
+		return Period{}, fmt.Errorf("period %s has an empty definition", name)
+	}
+	p := Period{Name: name, Kind: fields[0]}
+
+	var anchor string
+	if len(fields) > 1 {
+		anchor = fields[1]
+	}
+	if len(fields) > 2 {
+		return Period{}, fmt.Errorf("period %s: unexpected arguments %q", name, fields[2:])
+	}
+
+	if p.Kind == KindFiscal {
+		return Period{}, fmt.Errorf("period %s: fiscal periods are defined by their pattern (e.g. 4-4-5)", name)
+	}
+	if m := fiscalPattern.FindStringSubmatch(p.Kind); m != nil {
+		p.Kind = KindFiscal
+		total := 0
+		for i := range p.weeks {
+			p.weeks[i], _ = strconv.Atoi(m[i+1])
+			if p.weeks[i] < 1 {
+				return Period{}, fmt.Errorf("period %s: fiscal pattern %s has a month without weeks", name, fields[0])
+			}
+			total += p.weeks[i]
+		}
+		if total != 13 {
+			return Period{}, fmt.Errorf("period %s: fiscal pattern %s must add up to 13 weeks", name, fields[0])
+		}
+	}
+
+	switch p.Kind {
+	case KindWeekly:
+		if anchor == "" {
+			// 2024-01-01 was a Monday
+			anchor = "2024-01-01"
+		}
+	case KindBiweekly, KindFiscal:
+		if anchor == "" {
+			return Period{}, fmt.Errorf("period %s: %s periods require an anchor date", name, fields[0])
+		}
+	case KindSemimonthly, KindMonthly:
+		if anchor != "" {
+			return Period{}, fmt.Errorf("period %s: %s periods do not take an anchor date", name, p.Kind)
+		}
+	default:
+		return Period{}, fmt.Errorf("period %s: unrecognized period type %s", name, fields[0])
+	}
+
+	if anchor != "" {
+		t, err := time.Parse(anchorLayout, anchor)
+		if err != nil {
+			return Period{}, fmt.Errorf("period %s: invalid anchor date %s", name, anchor)
+		}
+		p.anchor = t
+	}
+	return p, nil
+}
+
+// Range returns the start (inclusive) and end (exclusive) of the period
+// containing t, shifted by offset periods. Both are midnight in t's location.
+func (p Period) Range(t time.Time, offset int) (time.Time, time.Time) {
+	loc := t.Location()
+	day := dayNumber(t)
+	switch p.Kind {
+	case KindWeekly, KindBiweekly:
+		length := 7
+		if p.Kind == KindBiweekly {
+			length = 14
+		}
+		anchor := dayNumber(p.anchor)
+		i := floorDiv(day-anchor, length) + offset
+		start := anchor + i*length
+		return fromDayNumber(start, loc), fromDayNumber(start+length, loc)
+	case KindSemimonthly:
+		y, m, d := t.Date()
+		i := y*24 + int(m-1)*2 + offset
+		if d > 15 {
+			i++
+		}
+		return semimonthStart(i, loc), semimonthStart(i+1, loc)
+	case KindMonthly:
+		y, m, _ := t.Date()
+		i := y*12 + int(m-1) + offset
+		return monthStart(i, loc), monthStart(i+1, loc)
+	case KindFiscal:
+		anchor := dayNumber(p.anchor)
+		year := floorDiv(day-anchor, fiscalYear)
+		dayOfYear := day - anchor - year*fiscalYear
+		month := 0
+		for month < 11 && p.fiscalMonthStart(month+1) <= dayOfYear {
+			month++
+		}
+		i := year*12 + month + offset
+		return fromDayNumber(p.fiscalStart(anchor, i), loc), fromDayNumber(p.fiscalStart(anchor, i+1), loc)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// Returns the first day of the i-th fiscal month after the anchor.
+func (p Period) fiscalStart(anchor, i int) int {
+	year := floorDiv(i, 12)
+	return anchor + year*fiscalYear + p.fiscalMonthStart(i-year*12)
+}
+
+// Returns the offset (in days) of the given fiscal month from the start of
+// the fiscal year.
+func (p Period) fiscalMonthStart(month int) int {
+	weeks := 0
+	for i := range month {
+		weeks += p.weeks[i%3]
+	}
+	return weeks * 7
+}
+
+// Resolve a period reference (e.g. `payroll`, `payroll-1`) relative to now.
+// The lookup function is used to retrieve the period definition by name.
+func Resolve(now time.Time, ref string, lookup func(name string) (string, error)) (time.Time, time.Time, error) {
+	name, offset, err := ParseReference(ref)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	spec, err := lookup(name)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("looking up period %s: %w", name, err)
+	}
+	p, err := Parse(name, spec)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start, end := p.Range(now, offset)
+	return start, end, nil
+}
+
+// ParseReference splits a period reference into the period name and offset.
+func ParseReference(ref string) (string, int, error) {
+	m := referencePattern.FindStringSubmatch(ref)
+	if m == nil {
+		return "", 0, fmt.Errorf("invalid period reference: %q", ref)
+	}
+	var offset int
+	if m[2] != "" {
+		offset, _ = strconv.Atoi(m[2])
+	}
+	return m[1], offset, nil
+}
+
+// Returns the number of days between the Unix epoch and the date of t.
+func dayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func fromDayNumber(n int, loc *time.Location) time.Time {
+	y, m, d := time.Unix(int64(n)*86400, 0).UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+func semimonthStart(i int, loc *time.Location) time.Time {
+	day := 1
+	if i%2 != 0 {
+		day = 16
+	}
+	return time.Date(i/24, time.Month((i%24)/2+1), day, 0, 0, 0, 0, loc)
+}
+
+func monthStart(i int, loc *time.Location) time.Time {
+	return time.Date(i/12, time.Month(i%12+1), 1, 0, 0, 0, 0, loc)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
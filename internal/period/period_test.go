@@ -0,0 +1,108 @@
+package period
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PeriodSuite struct {
+	suite.Suite
+}
+
+func TestPeriodSuite(t *testing.T) {
+	suite.Run(t, new(PeriodSuite))
+}
+
+func (suite *PeriodSuite) TestRange() {
+	tcs := []struct {
+		spec   string
+		date   string
+		offset int
+		start  string
+		end    string
+	}{
+		{"semimonthly", "2026-01-10", 0, "2026-01-01", "2026-01-16"},
+		{"semimonthly", "2026-01-16", 0, "2026-01-16", "2026-02-01"},
+		{"semimonthly", "2026-01-10", -1, "2025-12-16", "2026-01-01"},
+		{"semimonthly", "2026-02-20", 1, "2026-03-01", "2026-03-16"},
+		{"monthly", "2026-01-31", -1, "2025-12-01", "2026-01-01"},
+		{"weekly", "2026-01-01", 0, "2025-12-29", "2026-01-05"},
+		{"weekly 2026-01-04", "2026-01-01", 0, "2025-12-28", "2026-01-04"},
+		{"biweekly 2026-01-05", "2026-01-18", 0, "2026-01-05", "2026-01-19"},
+		{"biweekly 2026-01-05", "2026-01-04", 0, "2025-12-22", "2026-01-05"},
+		{"biweekly 2026-01-05", "2026-01-19", -1, "2026-01-05", "2026-01-19"},
+		{"4-4-5 2025-12-29", "2026-01-25", 0, "2025-12-29", "2026-01-26"},
+		{"4-4-5 2025-12-29", "2026-03-01", 0, "2026-02-23", "2026-03-30"},
+		{"4-4-5 2025-12-29", "2026-03-30", 0, "2026-03-30", "2026-04-27"},
+		{"4-4-5 2025-12-29", "2025-12-28", 0, "2025-11-24", "2025-12-29"},
+		{"5-4-4 2025-12-29", "2026-01-01", 1, "2026-02-02", "2026-03-02"},
+	}
+	for _, tc := range tcs {
+		p, err := Parse("test", tc.spec)
+		suite.Require().NoError(err, tc.spec)
+		date, err := time.ParseInLocation(anchorLayout, tc.date, time.Local)
+		suite.Require().NoError(err)
+		start, end := p.Range(date.Add(13*time.Hour), tc.offset)
+		msg := fmt.Sprintf("%s %s %+d", tc.spec, tc.date, tc.offset)
+		suite.Equal(tc.start, start.Format(anchorLayout), msg)
+		suite.Equal(tc.end, end.Format(anchorLayout), msg)
+		suite.Equal(time.Local, start.Location())
+	}
+}
+
+func (suite *PeriodSuite) TestParse_Errors() {
+	specs := []string{
+		"",
+		"fortnightly",
+		"biweekly",
+		"4-4-4 2026-01-01",
+		"4-4-5",
+		"fiscal 2026-01-01",
+		"0-6-7 2026-01-01",
+		"monthly 2026-01-01",
+		"weekly 01/01/2026",
+	}
+	for _, spec := range specs {
+		_, err := Parse("test", spec)
+		suite.Error(err, spec)
+	}
+}
+
+func (suite *PeriodSuite) TestParseReference() {
+	tcs := []struct {
+		ref    string
+		name   string
+		offset int
+	}{
+		{"payroll", "payroll", 0},
+		{"payroll-1", "payroll", -1},
+		{"payroll+2", "payroll", 2},
+		{"client-a-12", "client-a", -12},
+	}
+	for _, tc := range tcs {
+		name, offset, err := ParseReference(tc.ref)
+		suite.Require().NoError(err)
+		suite.Equal(tc.name, name)
+		suite.Equal(tc.offset, offset)
+	}
+}
+
+func (suite *PeriodSuite) TestResolve() {
+	lookup := func(name string) (string, error) {
+		if name == "payroll" {
+			return "semimonthly", nil
+		}
+		return "", fmt.Errorf("undefined")
+	}
+	now := time.Date(2026, 1, 20, 9, 0, 0, 0, time.Local)
+	start, end, err := Resolve(now, "payroll-1", lookup)
+	suite.Require().NoError(err)
+	suite.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), start)
+	suite.Equal(time.Date(2026, 1, 16, 0, 0, 0, 0, time.Local), end)
+
+	_, _, err = Resolve(now, "sprint", lookup)
+	suite.Error(err)
+}
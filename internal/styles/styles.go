@@ -17,10 +17,14 @@ var (
 
 var (
 	// Styles for table formatting
-	BaseStyle     = lipgloss.NewStyle().Padding(0, 1).Foreground(ColorPrimaryText)
-	BorderStyle   = lipgloss.NewStyle().Foreground(ColorStructure)
-	HeaderStyle   = BaseStyle.Foreground(ColorPrimaryText)
-	EvenRowStyle  = BaseStyle
-	OddRowStyle   = BaseStyle
-	TotalRowStyle = EvenRowStyle.Foreground(ColorAccent)
+	BaseStyle        = lipgloss.NewStyle().Padding(0, 1).Foreground(ColorPrimaryText)
+	BorderStyle      = lipgloss.NewStyle().Foreground(ColorStructure)
+	HeaderStyle      = BaseStyle.Foreground(ColorPrimaryText)
+	EvenRowStyle     = BaseStyle
+	OddRowStyle      = BaseStyle
+	TotalRowStyle    = EvenRowStyle.Foreground(ColorAccent)
+	SubtotalRowStyle = EvenRowStyle.Bold(true)
+	FooterStyle      = lipgloss.NewStyle().Foreground(ColorMutedText)
+	DimmedStyle      = BaseStyle.Foreground(ColorMutedText)
+	CursorStyle      = BaseStyle.Foreground(ColorAccent).Reverse(true)
 )
@@ -0,0 +1,108 @@
+package timecard
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Allocation policies for intervals with multiple tags
+const (
+	// Count the full duration in every tag
+	AllocateFull = "full"
+
+	// Split the duration evenly across tags
+	AllocateSplit = "split"
+
+	// Count the duration in the primary tag only
+	AllocatePrimary = "primary"
+)
+
+// allocator decides how much of an interval's duration each of its tags
+// receives.
+type allocator struct {
+	policy string
+
+	// Tags which take priority when choosing the primary tag, in order
+	priority []string
+
+	// Pattern matched against tags when choosing the primary tag
+	pattern *regexp.Regexp
+}
+
+func newAllocator(options TimecardOptions) (allocator, error) {
+	a := allocator{
+		policy:   options.Allocation,
+		priority: options.PrimaryTags,
+	}
+	switch a.policy {
+	case AllocateFull, AllocateSplit, AllocatePrimary:
+	default:
+		return allocator{}, fmt.Errorf("unrecognized allocation policy: %s", a.policy)
+	}
+	if options.PrimaryPattern != "" {
+		p, err := regexp.Compile(options.PrimaryPattern)
+		if err != nil {
+			return allocator{}, fmt.Errorf("primary pattern %s failed to compile as regex: %w", options.PrimaryPattern, err)
+		}
+		a.pattern = p
+	}
+	return a, nil
+}
+
+// Returns the tags which receive a share of the interval's duration.
+func (a allocator) tags(tags []string) []string {
+	if a.policy != AllocatePrimary || len(tags) <= 1 {
+		return tags
+	}
+	return []string{a.primary(tags)}
+}
+
+// Returns the primary tag: the first tag from the priority list, or else the
+// first tag matching the pattern, or else the first tag.
+func (a allocator) primary(tags []string) string {
+	for _, tag := range a.priority {
+		if slices.Contains(tags, tag) {
+			return tag
+		}
+	}
+	if a.pattern != nil {
+		for _, tag := range tags {
+			if a.pattern.MatchString(tag) {
+				return tag
+			}
+		}
+	}
+	return tags[0]
+}
+
+// Returns the share of duration d received by a row to which n of the
+// interval's tags (out of total) belong.
+func (a allocator) share(d time.Duration, n, total int) time.Duration {
+	if a.policy != AllocateSplit || total <= 1 {
+		return d
+	}
+	return d * time.Duration(n) / time.Duration(total)
+}
+
+// Returns a human-readable description of the allocation policy.
+func (a allocator) String() string {
+	switch a.policy {
+	case AllocateSplit:
+		return "split (time is divided evenly across tags)"
+	case AllocatePrimary:
+		var rules []string
+		if len(a.priority) > 0 {
+			rules = append(rules, "first of "+strings.Join(a.priority, ", "))
+		}
+		if a.pattern != nil {
+			rules = append(rules, "first tag matching "+a.pattern.String())
+		}
+		rules = append(rules, "first tag")
+		return "primary (time is counted in one tag: " + strings.Join(rules, ", else ") + ")"
+	default:
+		return "full (time is counted in every tag)"
+	}
+}
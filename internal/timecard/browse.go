@@ -0,0 +1,68 @@
+package timecard
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kgoettler/twe/internal/styles"
+)
+
+// Record that an interval (an index into intervals) was counted in the given
+// cell.
+func (td *TimecardData) addSource(row string, col time.Time, i int) {
+	if _, ok := td.sources[row]; !ok {
+		td.sources[row] = make(map[time.Time][]int)
+	}
+	if !slices.Contains(td.sources[row][col], i) {
+		td.sources[row][col] = append(td.sources[row][col], i)
+	}
+}
+
+// CellIntervals returns the intervals counted in a cell of the timecard,
+// sorted by start time. Rows and columns are indices into TemplateData.Rows
+// and TemplateData.Columns; a row or column past the last one stands for the
+// total row or column, and covers all of them.
+func (td TimecardData) CellIntervals(row, col int) []TemplateInterval {
+	rows := td.rows
+	if row < len(td.rows) {
+		rows = td.rows[row : row+1]
+	}
+	columns := td.columns
+	if col < len(td.columns) {
+		columns = td.columns[col : col+1]
+	}
+	indices := []int{}
+	for _, r := range rows {
+		for _, c := range columns {
+			for _, i := range td.sources[r][c] {
+				if !slices.Contains(indices, i) {
+					indices = append(indices, i)
+				}
+			}
+		}
+	}
+	slices.Sort(indices)
+	out := make([]TemplateInterval, len(indices))
+	for k, i := range indices {
+		interval := td.intervals[i]
+		out[k] = newTemplateInterval(interval, td.openStarts[interval.Start.Time])
+	}
+	return out
+}
+
+// StringTableCursor renders the timecard as a table (see StringTable) with the
+// cell at the given row and column highlighted. Rows and columns are indexed
+// as in CellIntervals. The table is not fitted into TimecardOptions.Width, so
+// the cursor stays on a single table, and transposed timecards are laid out
+// as usual.
+func (td TimecardData) StringTableCursor(row, col int) string {
+	td.options.Transpose = false
+	g := td.grid(false, td.formatCell, td.noteStyle(true))
+	g.cursor = &gridCell{min(row, len(td.rows)) + 1, g.labelCols + col}
+	lines := []string{renderTable(g)}
+	for _, line := range append(g.footnotes, td.footer()...) {
+		lines = append(lines, styles.FooterStyle.Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
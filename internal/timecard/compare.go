@@ -0,0 +1,309 @@
+package timecard
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kgoettler/twe/internal/styles"
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// Status of tags which were only recorded in one of the compared ranges
+const (
+	Appeared    = "appeared"
+	Disappeared = "disappeared"
+)
+
+// Comparison compares the time recorded for each tag over two report ranges.
+// The first range is the baseline: deltas are the time recorded in the second
+// range minus the time recorded in the first.
+type Comparison struct {
+	a, b TimecardData
+
+	// Union of the rows of both timecards, sorted by path
+	rows  []string
+	paths map[string][]string
+
+	// Rows which are subtotals of the rows nested under them
+	subtotals map[string]bool
+
+	options TimecardOptions
+}
+
+// RunCompare compares two reports, in the format given by
+// TimecardOptions.OutputFormat (table, csv, tsv or json).
+func RunCompare(a, b *timew.Report, options TimecardOptions) (string, error) {
+	c, err := NewComparison(a, b, options)
+	if err != nil {
+		return "", err
+	}
+	switch options.OutputFormat {
+	case "table":
+		return c.StringTable(), nil
+	case "csv":
+		return c.StringCSV(',')
+	case "tsv":
+		return c.StringCSV('\t')
+	case "json":
+		return c.StringJSON()
+	default:
+		return "", fmt.Errorf("unrecognized table format: %s", options.OutputFormat)
+	}
+}
+
+// NewComparison generates the timecard data of both reports with the same
+// options and lines up their rows.
+func NewComparison(a, b *timew.Report, options TimecardOptions) (Comparison, error) {
+	dataA, err := NewTimecardData(a, options)
+	if err != nil {
+		return Comparison{}, fmt.Errorf("generating data for first range: %w", err)
+	}
+	dataB, err := NewTimecardData(b, options)
+	if err != nil {
+		return Comparison{}, fmt.Errorf("generating data for second range: %w", err)
+	}
+	c := Comparison{
+		a:         dataA,
+		b:         dataB,
+		paths:     make(map[string][]string),
+		subtotals: make(map[string]bool),
+		options:   dataA.options,
+	}
+	for _, td := range []TimecardData{dataA, dataB} {
+		for _, row := range td.rows {
+			if _, ok := c.paths[row]; ok {
+				continue
+			}
+			c.rows = append(c.rows, row)
+			c.paths[row] = td.path(row)
+		}
+		for row := range td.subtotals {
+			c.subtotals[row] = true
+		}
+	}
+	slices.SortFunc(c.rows, func(x, y string) int {
+		return slices.Compare(c.paths[x], c.paths[y])
+	})
+	return c, nil
+}
+
+// Returns the total time recorded for the given row in each range.
+func (c Comparison) totals(row string) (time.Duration, time.Duration) {
+	return c.a.rowTotals[row], c.b.rowTotals[row]
+}
+
+// Returns whether the given row appeared or disappeared in the second range,
+// or an empty string if it was recorded in both.
+func (c Comparison) status(row string) string {
+	a, b := c.totals(row)
+	switch {
+	case a == 0 && b != 0:
+		return Appeared
+	case a != 0 && b == 0:
+		return Disappeared
+	default:
+		return ""
+	}
+}
+
+// Returns the change from a to b as a percentage of a, or false if a is zero.
+func percentChange(a, b time.Duration) (float64, bool) {
+	if a == 0 {
+		return 0, false
+	}
+	return float64(b-a) / float64(a) * 100, true
+}
+
+// Format a percentage change with an explicit sign (e.g. +12.5%), or as an
+// empty string if it is undefined.
+func formatPercent(p float64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	p = math.Round(p*10) / 10
+	if p == 0 {
+		return "0%"
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%+.1f", p), ".0") + "%"
+}
+
+// Returns a header label for the range of the given timecard, or fallback if
+// the report did not define one.
+func rangeLabel(td TimecardData, fallback string) string {
+	if td.start.IsZero() || td.end.IsZero() {
+		return fallback
+	}
+	// The range end is exclusive
+	end := td.end.Add(-time.Nanosecond)
+	return td.start.Format(ISODayFormat) + " - " + end.Format(ISODayFormat)
+}
+
+// Returns the comparison laid out for rendering, with one record per tag
+// followed by a total row. Durations are formatted using the format function,
+// with percentages relative to the total of each range.
+func (c Comparison) grid(format func(d, whole time.Duration) string) grid {
+	g := newGrid([]string{"Tag", rangeLabel(c.a, "A"), rangeLabel(c.b, "B"), "Delta", "Delta %", "Status"})
+	for i, row := range c.rows {
+		a, b := c.totals(row)
+		path := c.paths[row]
+		g.records = append(g.records, []string{
+			strings.Repeat("  ", len(path)-1) + path[len(path)-1],
+			format(a, c.a.total()),
+			format(b, c.b.total()),
+			formatSigned(b-a, c.options.Units),
+			formatPercent(percentChange(a, b)),
+			c.status(row),
+		})
+		if c.subtotals[row] {
+			g.subtotalRows[i+1] = true
+		}
+	}
+	a, b := c.a.total(), c.b.total()
+	g.records = append(g.records, []string{
+		"TOTAL",
+		format(a, a),
+		format(b, b),
+		formatSigned(b-a, c.options.Units),
+		formatPercent(percentChange(a, b)),
+		"",
+	})
+	g.totalRows = 1
+	return g
+}
+
+// StringTable renders the comparison as a table.
+func (c Comparison) StringTable() string {
+	ts := renderTable(c.grid(c.a.formatCell))
+	for _, line := range c.footer() {
+		ts += "\n" + styles.FooterStyle.Render(line)
+	}
+	return ts
+}
+
+// Returns lines to print below the comparison.
+func (c Comparison) footer() []string {
+	lines := []string{}
+	for _, status := range []string{Appeared, Disappeared} {
+		tags := []string{}
+		for _, row := range c.rows {
+			if c.status(row) == status {
+				tags = append(tags, strings.Join(c.paths[row], " / "))
+			}
+		}
+		if len(tags) > 0 {
+			lines = append(lines, fmt.Sprintf("%s%s: %s", strings.ToUpper(status[:1]), status[1:], strings.Join(tags, ", ")))
+		}
+	}
+	return lines
+}
+
+// StringCSV renders the comparison as delimiter-separated values (e.g. ','
+// for CSV or '\t' for TSV). Zero durations are left blank.
+func (c Comparison) StringCSV(comma rune) (string, error) {
+	var builder strings.Builder
+	w := csv.NewWriter(&builder)
+	w.Comma = comma
+	g := c.grid(func(d, whole time.Duration) string {
+		return formatShare(d, whole, c.options.Units)
+	})
+	if err := w.WriteAll(g.records); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+// JSONComparison is the document written by `twe compare --format json`.
+type JSONComparison struct {
+	// Version of the document schema (see JSONSchemaVersion)
+	SchemaVersion int `json:"schema_version"`
+
+	// Ranges of the two reports. Each is null if the report did not define
+	// one.
+	Ranges [2]*JSONRange `json:"ranges"`
+
+	// One entry per tag recorded in either range, in display order
+	Rows []JSONComparisonRow `json:"rows"`
+
+	// Total time recorded in each range, their difference and the change as a
+	// percentage of the first
+	Totals  [2]JSONDuration `json:"totals"`
+	Delta   JSONDuration    `json:"delta"`
+	Percent *float64        `json:"percent"`
+}
+
+// JSONComparisonRow compares the time recorded for a single tag.
+type JSONComparisonRow struct {
+	// Tag (or group) name
+	Tag string `json:"tag"`
+
+	// Names of the groups the row is nested under, followed by Tag
+	Path []string `json:"path"`
+
+	// True if the row is a subtotal of the rows nested under it
+	Subtotal bool `json:"subtotal"`
+
+	// Total time recorded for the tag in each range
+	Totals [2]JSONDuration `json:"totals"`
+
+	// Time recorded in the second range minus time recorded in the first
+	Delta JSONDuration `json:"delta"`
+
+	// Delta as a percentage of the time recorded in the first range. Null if
+	// the tag was not recorded in the first range.
+	Percent *float64 `json:"percent"`
+
+	// "appeared" or "disappeared" if the tag was only recorded in one range
+	Status string `json:"status,omitempty"`
+}
+
+// JSON returns the comparison as a JSONComparison document.
+func (c Comparison) JSON() JSONComparison {
+	out := JSONComparison{
+		SchemaVersion: JSONSchemaVersion,
+		Rows:          make([]JSONComparisonRow, len(c.rows)),
+	}
+	for i, td := range []TimecardData{c.a, c.b} {
+		if !td.start.IsZero() && !td.end.IsZero() {
+			out.Ranges[i] = &JSONRange{Start: td.start, End: td.end}
+		}
+	}
+	percent := func(a, b time.Duration) *float64 {
+		if p, ok := percentChange(a, b); ok {
+			return &p
+		}
+		return nil
+	}
+	for i, row := range c.rows {
+		a, b := c.totals(row)
+		path := c.paths[row]
+		out.Rows[i] = JSONComparisonRow{
+			Tag:      path[len(path)-1],
+			Path:     path,
+			Subtotal: c.subtotals[row],
+			Totals:   [2]JSONDuration{newJSONDuration(a), newJSONDuration(b)},
+			Delta:    newJSONDuration(b - a),
+			Percent:  percent(a, b),
+			Status:   c.status(row),
+		}
+	}
+	a, b := c.a.total(), c.b.total()
+	out.Totals = [2]JSONDuration{newJSONDuration(a), newJSONDuration(b)}
+	out.Delta = newJSONDuration(b - a)
+	out.Percent = percent(a, b)
+	return out
+}
+
+// StringJSON renders the comparison as an indented JSON document (see
+// JSONComparison).
+func (c Comparison) StringJSON() (string, error) {
+	b, err := json.MarshalIndent(c.JSON(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,23 @@
+package timecard
+
+import (
+	"encoding/csv"
+	"strings"
+	"time"
+)
+
+// StringCSV renders the timecard as delimiter-separated values (e.g. ',' for
+// CSV or '\t' for TSV). Fields are quoted per RFC 4180, periods are written as
+// ISO 8601 and empty cells are left blank.
+func (td TimecardData) StringCSV(comma rune) (string, error) {
+	var builder strings.Builder
+	w := csv.NewWriter(&builder)
+	w.Comma = comma
+	g := td.grid(true, func(d, whole time.Duration) string {
+		return formatShare(d, whole, td.options.Units)
+	}, td.noteStyle(false))
+	if err := w.WriteAll(g.records); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
@@ -0,0 +1,36 @@
+package timecard
+
+import "time"
+
+// Add a column for the given period, if there is none yet.
+func (td *TimecardData) addColumn(col time.Time) {
+	if _, ok := td.totals[col]; !ok {
+		td.totals[col] = 0
+		td.columns = append(td.columns, col)
+	}
+}
+
+// Add a column for every period of the report range, so days without any
+// recorded time are shown too. If the WorkdaysOnly option is set, no columns
+// are added for weekends and holidays (days off with recorded time are still
+// shown, so the totals add up).
+func (td *TimecardData) addRangeColumns() {
+	if td.start.IsZero() || td.end.IsZero() {
+		return
+	}
+	for date := midnightLocal(td.start); date.Before(td.end); date = date.AddDate(0, 0, 1) {
+		if td.options.WorkdaysOnly && td.dayOff(date) {
+			continue
+		}
+		td.addColumn(td.bucket(date))
+	}
+}
+
+// Returns true if the given column is a single day which is a weekend or a
+// holiday.
+func (td TimecardData) dayOff(col time.Time) bool {
+	if td.options.Granularity != ByDay {
+		return false
+	}
+	return col.Weekday() == time.Saturday || col.Weekday() == time.Sunday || td.holidays[col]
+}
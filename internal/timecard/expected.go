@@ -0,0 +1,209 @@
+package timecard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpectedWeeklyConfig is the timewarrior.cfg setting which defines the
+// number of hours expected each week (e.g. `twe.expected.weekly = 40`).
+const ExpectedWeeklyConfig = "twe.expected.weekly"
+
+// Prefixes of the Timewarrior settings which define working hours (e.g.
+// `exclusions.monday = <9:00 12:00-12:30 >17:30`) and holidays (e.g.
+// `holidays.en-US.2026_01_01 = New Year's Day`).
+const (
+	exclusionsConfigPrefix = "exclusions."
+	holidaysConfigPrefix   = "holidays."
+	holidayLayout          = "2006_01_02"
+)
+
+// expectation computes the number of hours expected to be worked each day.
+type expectation struct {
+	// Hours expected each week, spread evenly over Monday to Friday. If zero,
+	// working hours are taken from the exclusions.
+	weekly time.Duration
+
+	// Working hours on each day of the week, from the `exclusions.<day>`
+	// settings. Days without exclusions are not working days.
+	workday map[time.Weekday]time.Duration
+
+	// Days on which no hours are expected
+	holidays map[time.Time]bool
+}
+
+func newExpectation(options TimecardOptions, config map[string]string) (expectation, error) {
+	e := expectation{
+		weekly:   time.Duration(options.ExpectedWeekly * float64(time.Hour)),
+		workday:  make(map[time.Weekday]time.Duration),
+		holidays: holidaysFromConfig(config),
+	}
+	if e.weekly == 0 && config[ExpectedWeeklyConfig] != "" {
+		hours, err := strconv.ParseFloat(config[ExpectedWeeklyConfig], 64)
+		if err != nil {
+			return expectation{}, fmt.Errorf("%s: invalid number of hours %q", ExpectedWeeklyConfig, config[ExpectedWeeklyConfig])
+		}
+		e.weekly = time.Duration(hours * float64(time.Hour))
+	}
+	for key, value := range config {
+		if name, ok := strings.CutPrefix(key, exclusionsConfigPrefix); ok {
+			day, ok := weekdays[name]
+			if !ok {
+				continue
+			}
+			working, err := workingHours(value)
+			if err != nil {
+				return expectation{}, fmt.Errorf("%s: %w", key, err)
+			}
+			e.workday[day] = working
+		}
+	}
+	if e.weekly == 0 && len(e.workday) == 0 {
+		return expectation{}, fmt.Errorf("no expected hours configured: set %s or exclusions.<day>", ExpectedWeeklyConfig)
+	}
+	return e, nil
+}
+
+// Returns the dates (midnight) of the holidays defined by the
+// `holidays.<locale>.<YYYY_MM_DD>` settings.
+func holidaysFromConfig(config map[string]string) map[time.Time]bool {
+	out := make(map[time.Time]bool)
+	for key := range config {
+		if name, ok := strings.CutPrefix(key, holidaysConfigPrefix); ok {
+			_, date, _ := strings.Cut(name, ".")
+			t, err := time.ParseInLocation(holidayLayout, date, time.Local)
+			if err == nil {
+				out[t] = true
+			}
+		}
+	}
+	return out
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Returns the number of hours expected on the given date (midnight).
+func (e expectation) day(date time.Time) time.Duration {
+	if e.holidays[date] {
+		return 0
+	}
+	if e.weekly > 0 {
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			return 0
+		}
+		return e.weekly / 5
+	}
+	return e.workday[date.Weekday()]
+}
+
+// Returns the working hours left by a Timewarrior exclusion (e.g. `<9:00
+// 12:00-12:30 >17:30`), i.e. the length of the day minus the excluded time.
+func workingHours(exclusion string) (time.Duration, error) {
+	var excluded [24 * 60]bool
+	for _, field := range strings.Fields(exclusion) {
+		var from, to int
+		var err error
+		switch {
+		case strings.HasPrefix(field, "<"):
+			to, err = parseClock(field[1:])
+		case strings.HasPrefix(field, ">"):
+			from, err = parseClock(field[1:])
+			to = len(excluded)
+		default:
+			start, end, ok := strings.Cut(field, "-")
+			if !ok {
+				return 0, fmt.Errorf("invalid exclusion %q", field)
+			}
+			from, err = parseClock(start)
+			if err == nil {
+				to, err = parseClock(end)
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		for m := from; m < to; m++ {
+			excluded[m] = true
+		}
+	}
+	var working time.Duration
+	for _, e := range excluded {
+		if !e {
+			working += time.Minute
+		}
+	}
+	return working, nil
+}
+
+// Parse a time of day (e.g. `9:00` or `17:30`) as minutes after midnight.
+func parseClock(s string) (int, error) {
+	hours, minutes, _ := strings.Cut(s, ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	var m int
+	if minutes != "" {
+		m, err = strconv.Atoi(minutes)
+		if err != nil || m < 0 || m > 59 {
+			return 0, fmt.Errorf("invalid time of day %q", s)
+		}
+	}
+	return min(h*60+m, 24*60), nil
+}
+
+// Compute the hours expected in each column of the report. Only days up to
+// today are expected, so the balance of the current period is not skewed by
+// days which have not happened yet. Columns are added for working days
+// without any recorded time, even if the report has no range.
+func (td *TimecardData) addExpected(e expectation, now time.Time) {
+	td.expected = make(timecardCol)
+	start, end := td.start, td.end
+	if start.IsZero() || end.IsZero() {
+		if len(td.columns) == 0 {
+			return
+		}
+		start = td.columns[0]
+		end = td.columns[len(td.columns)-1].AddDate(0, 0, 1)
+	}
+	today := midnightLocal(now)
+	for date := midnightLocal(start); date.Before(end) && !date.After(today); date = date.AddDate(0, 0, 1) {
+		hours := e.day(date)
+		if hours == 0 {
+			continue
+		}
+		col := td.bucket(date)
+		td.expected[col] += hours
+		td.expectedTotal += hours
+		td.addColumn(col)
+	}
+}
+
+// Returns the difference between the time recorded and the time expected in
+// the report.
+func (td TimecardData) delta() time.Duration {
+	return td.total() - td.expectedTotal
+}
+
+// Format a duration with an explicit sign (e.g. +1.5 or -0:45).
+func formatSigned(d time.Duration, units string) string {
+	s := formatDuration(d, units)
+	switch {
+	case s == "":
+		return "0"
+	case d > 0:
+		return "+" + s
+	default:
+		return s
+	}
+}
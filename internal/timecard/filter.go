@@ -0,0 +1,112 @@
+package timecard
+
+import (
+	"fmt"
+	"regexp"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// Filter modes
+const (
+	// Include intervals with a tag matching any filter
+	FilterAny = "any"
+
+	// Include intervals where every filter matches at least one tag
+	FilterAll = "all"
+)
+
+// intervalFilter decides which intervals are included in the timecard.
+type intervalFilter struct {
+	includes           []*regexp.Regexp
+	excludes           []*regexp.Regexp
+	annotations        []*regexp.Regexp
+	annotationExcludes []*regexp.Regexp
+	all                bool
+}
+
+func newIntervalFilter(options TimecardOptions) (intervalFilter, error) {
+	var f intervalFilter
+	switch options.FilterMode {
+	case "", FilterAny:
+	case FilterAll:
+		f.all = true
+	default:
+		return intervalFilter{}, fmt.Errorf("unrecognized filter mode: %s", options.FilterMode)
+	}
+	var err error
+	if f.includes, err = compilePatterns("filter", options.Filters); err != nil {
+		return intervalFilter{}, err
+	}
+	if f.excludes, err = compilePatterns("exclude", options.Excludes); err != nil {
+		return intervalFilter{}, err
+	}
+	if f.annotations, err = compilePatterns("annotation filter", options.AnnotationFilters); err != nil {
+		return intervalFilter{}, err
+	}
+	if f.annotationExcludes, err = compilePatterns("annotation exclude", options.AnnotationExcludes); err != nil {
+		return intervalFilter{}, err
+	}
+	return f, nil
+}
+
+// Split intervals into those which are included and those which are excluded.
+func (f intervalFilter) apply(intervals []timew.Interval) ([]timew.Interval, []timew.Interval) {
+	included := []timew.Interval{}
+	excluded := []timew.Interval{}
+	for _, interval := range intervals {
+		if f.keep(interval) {
+			included = append(included, interval)
+		} else {
+			excluded = append(excluded, interval)
+		}
+	}
+	return included, excluded
+}
+
+// Returns true if the interval is included.
+func (f intervalFilter) keep(interval timew.Interval) bool {
+	if len(f.includes) > 0 {
+		if f.all {
+			for _, pattern := range f.includes {
+				if !matchAnyTag(interval.Tags, pattern) {
+					return false
+				}
+			}
+		} else if !matchAnyTag(interval.Tags, f.includes...) {
+			return false
+		}
+	}
+	if matchAnyTag(interval.Tags, f.excludes...) {
+		return false
+	}
+	if len(f.annotations) > 0 && !matchAny(interval.Annotation, f.annotations) {
+		return false
+	}
+	if matchAny(interval.Annotation, f.annotationExcludes) {
+		return false
+	}
+	return true
+}
+
+// Returns true if any of the tags matches any of the patterns.
+func matchAnyTag(tags []string, patterns ...*regexp.Regexp) bool {
+	for _, tag := range tags {
+		if matchAny(tag, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePatterns(kind string, patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		p, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s failed to compile as regex: %w", kind, pattern, err)
+		}
+		out[i] = p
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package timecard
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Narrowest width to which label columns (e.g. tags) are truncated to fit a
+// table into the terminal
+const minLabelWidth = 10
+
+// Ellipsis marking truncated labels
+const ellipsis = "…"
+
+// Returns the width of each column of the grid when rendered as a table,
+// including cell padding and the border to its left.
+func (g grid) columnWidths() []int {
+	widths := make([]int, len(g.records[0]))
+	for _, record := range g.records {
+		for j, field := range record {
+			widths[j] = max(widths[j], lipgloss.Width(field)+3)
+		}
+	}
+	return widths
+}
+
+// Returns the width of the grid when rendered as a table.
+func (g grid) tableWidth() int {
+	width := 1
+	for _, w := range g.columnWidths() {
+		width += w
+	}
+	return width
+}
+
+// Split the grid into blocks which each fit into the given width when
+// rendered as a table. Labels are truncated first; if the grid is still too
+// wide, its columns are split into stacked blocks which each repeat the label
+// columns. Total columns are kept together in the last block.
+func (g grid) fit(width int) []grid {
+	if width <= 0 || g.tableWidth() <= width {
+		return []grid{g}
+	}
+	limit := max(minLabelWidth, width/(3*g.labelCols))
+	g = g.truncateLabels(limit)
+	if g.tableWidth() <= width {
+		return []grid{g}
+	}
+
+	widths := g.columnWidths()
+	labelWidth := 1
+	for _, w := range widths[:g.labelCols] {
+		labelWidth += w
+	}
+	ncols := len(widths)
+	blocks := []grid{}
+	block := []int{}
+	blockWidth := labelWidth
+	for j := g.labelCols; j < ncols-g.totalCols; j++ {
+		if len(block) > 0 && blockWidth+widths[j] > width {
+			blocks = append(blocks, g.columns(block))
+			block, blockWidth = []int{}, labelWidth
+		}
+		block = append(block, j)
+		blockWidth += widths[j]
+	}
+	// Keep total columns together, in a block of their own if need be
+	totalsWidth := 0
+	for _, w := range widths[ncols-g.totalCols:] {
+		totalsWidth += w
+	}
+	if len(block) > 0 && blockWidth+totalsWidth > width {
+		blocks = append(blocks, g.columns(block))
+		block = []int{}
+	}
+	for j := ncols - g.totalCols; j < ncols; j++ {
+		block = append(block, j)
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, g.columns(block))
+	}
+	return blocks
+}
+
+// Returns the grid with the label columns truncated to the given width.
+func (g grid) truncateLabels(width int) grid {
+	out := g
+	out.records = make([][]string, len(g.records))
+	for i, record := range g.records {
+		out.records[i] = slices.Clone(record)
+		for j := range g.labelCols {
+			out.records[i][j] = truncate(record[j], width)
+		}
+	}
+	return out
+}
+
+// Returns the grid with the label columns followed by the given columns.
+func (g grid) columns(cols []int) grid {
+	out := newGrid(nil)
+	out.records = make([][]string, len(g.records))
+	out.labelCols = g.labelCols
+	out.totalRows = g.totalRows
+	out.subtotalRows = g.subtotalRows
+	out.dimRows = g.dimRows
+	out.footnotes = g.footnotes
+	keep := append(make([]int, 0, g.labelCols+len(cols)), cols...)
+	for j := range g.labelCols {
+		keep = slices.Insert(keep, j, j)
+	}
+	ncols := len(g.records[0])
+	for k, j := range keep {
+		if j >= ncols-g.totalCols {
+			out.totalCols++
+		}
+		if g.subtotalCols[j] {
+			out.subtotalCols[k] = true
+		}
+		if g.dimCols[j] {
+			out.dimCols[k] = true
+		}
+	}
+	for i, record := range g.records {
+		out.records[i] = make([]string, len(keep))
+		for k, j := range keep {
+			out.records[i][k] = record[j]
+		}
+	}
+	return out
+}
+
+// Truncate a string to the given width, marking truncation with an ellipsis.
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+lipgloss.Width(ellipsis) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ellipsis
+}
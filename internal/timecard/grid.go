@@ -0,0 +1,308 @@
+package timecard
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Granularities (i.e. the period covered by each column)
+const (
+	ByDay   = "day"
+	ByWeek  = "week"
+	ByMonth = "month"
+)
+
+// grid is the timecard laid out as rows of text for rendering.
+type grid struct {
+	// Header first, followed by one record per row
+	records [][]string
+
+	// Number of leading columns which label the rows (e.g. tag and
+	// description)
+	labelCols int
+
+	// Number of trailing records which are totals (e.g. TOTAL, AMOUNT)
+	totalRows int
+
+	// Number of trailing columns which are totals
+	totalCols int
+
+	// Indices of records / columns which are group subtotals
+	subtotalRows map[int]bool
+	subtotalCols map[int]bool
+
+	// Indices of records / columns which are greyed out (e.g. days off)
+	dimRows map[int]bool
+	dimCols map[int]bool
+
+	// Footnotes referenced by cells, in order
+	footnotes []string
+
+	// Cell highlighted by a cursor, if any
+	cursor *gridCell
+}
+
+// gridCell is the position of a cell in a grid: the index of its record
+// (the header being record 0) and of its column.
+type gridCell struct {
+	i, j int
+}
+
+func newGrid(header []string) grid {
+	return grid{
+		records:      [][]string{header},
+		labelCols:    1,
+		subtotalRows: make(map[int]bool),
+		subtotalCols: make(map[int]bool),
+		dimRows:      make(map[int]bool),
+		dimCols:      make(map[int]bool),
+	}
+}
+
+// Returns true if record i is a total row or column j is a total column.
+func (g grid) isTotal(i, j int) bool {
+	return i >= len(g.records)-g.totalRows || j >= len(g.records[0])-g.totalCols
+}
+
+// Returns true if record i or column j is greyed out.
+func (g grid) isDimmed(i, j int) bool {
+	return g.dimRows[i] || g.dimCols[j]
+}
+
+// Returns true if record i or column j is a group subtotal.
+func (g grid) isSubtotal(i, j int) bool {
+	return g.subtotalRows[i] || g.subtotalCols[j]
+}
+
+// Returns the timecard laid out for rendering, with the same row/column/total
+// layout as StringTable. Column headers are ISO 8601 dates if iso is set;
+// durations are formatted using the format function, and notes are laid out
+// in the given style (whole is zero for durations which are not shares of the
+// time recorded). Unless iso is set, cells with running time are marked.
+// If the Transpose option is set, periods are laid out as rows and tags as
+// columns.
+func (td TimecardData) grid(iso bool, format func(d, whole time.Duration) string, notes noteStyle) grid {
+	header := []string{"Tag"}
+	// Descriptions are left out of transposed timecards, where they would
+	// form a second header row
+	describe := td.mapper != nil && !td.options.Transpose
+	if describe {
+		header = []string{"Code", "Description"}
+	}
+	for _, col := range td.columns {
+		header = append(header, td.columnLabel(col, iso))
+	}
+	if td.options.IncludeTotalCol {
+		header = append(header, "TOTAL")
+	}
+	if td.options.ShowAmounts {
+		header = append(header, td.amountLabel())
+	}
+	money := func(amount float64) string {
+		if amount == 0 {
+			return format(0, 0)
+		}
+		return formatAmount(amount)
+	}
+
+	g := newGrid(header)
+	if describe {
+		g.labelCols = 2
+	}
+	if td.options.DimDaysOff {
+		for j, col := range td.columns {
+			if td.dayOff(col) {
+				g.dimCols[g.labelCols+j] = true
+			}
+		}
+	}
+	for i, row := range td.rows {
+		record := []string{td.rowLabel(row)}
+		if describe {
+			record = append(record, td.description(row))
+		}
+		for _, col := range td.columns {
+			cell := format(td.data[row][col], td.whole(&col))
+			if !iso && td.running[row][col] {
+				cell += runningMarker
+			}
+			if notes == notesFootnotes && len(td.cellNotes(row, col)) > 0 {
+				marker, footnote := td.footnote(len(g.footnotes)+1, row, col)
+				cell += " " + marker
+				g.footnotes = append(g.footnotes, footnote)
+			}
+			record = append(record, cell)
+		}
+		if td.options.IncludeTotalCol {
+			record = append(record, format(td.rowTotals[row], td.whole(nil)))
+		}
+		if td.options.ShowAmounts {
+			record = append(record, money(td.rowAmount(row)))
+		}
+		g.records = append(g.records, record)
+		if td.subtotals[row] {
+			g.subtotalRows[i+1] = true
+		}
+	}
+	if td.options.IncludeTotalRow {
+		record := td.summaryLabel("TOTAL", describe)
+		for _, col := range td.columns {
+			record = append(record, format(td.totals[col], td.whole(&col)))
+		}
+		if td.options.IncludeTotalCol {
+			record = append(record, format(0, 0))
+		}
+		if td.options.ShowAmounts {
+			record = append(record, format(0, 0))
+		}
+		g.records = append(g.records, record)
+		g.totalRows++
+	}
+	if td.options.ShowAmounts {
+		record := td.summaryLabel(td.amountLabel(), describe)
+		for _, col := range td.columns {
+			record = append(record, money(td.columnAmount(col)))
+		}
+		if td.options.IncludeTotalCol {
+			record = append(record, format(0, 0))
+		}
+		record = append(record, money(td.totalAmount()))
+		g.records = append(g.records, record)
+		g.totalRows++
+		g.totalCols++
+	}
+	if td.options.ShowExpected {
+		actual := make(timecardCol)
+		delta := make(timecardCol)
+		for _, col := range td.columns {
+			actual[col] = td.totals[col]
+			delta[col] = td.totals[col] - td.expected[col]
+		}
+		for _, summary := range []struct {
+			label  string
+			values timecardCol
+			total  time.Duration
+		}{
+			{"EXPECTED", td.expected, td.expectedTotal},
+			{"ACTUAL", actual, td.expectedTotal + td.delta()},
+			{"DELTA", delta, td.delta()},
+		} {
+			// Balances are not shares of the time recorded, so they are
+			// shown in hours even with percentage units
+			record := td.summaryLabel(summary.label, describe)
+			for _, col := range td.columns {
+				record = append(record, format(summary.values[col], 0))
+			}
+			if td.options.IncludeTotalCol {
+				record = append(record, format(summary.total, 0))
+			}
+			if td.options.ShowAmounts {
+				record = append(record, format(0, 0))
+			}
+			g.records = append(g.records, record)
+			g.totalRows++
+		}
+	}
+	if td.options.IncludeTotalCol {
+		g.totalCols++
+	}
+	if notes == notesColumn {
+		// Notes go after the periods, ahead of the total columns
+		j := g.labelCols + len(td.columns)
+		for i := range g.records {
+			field := ""
+			switch {
+			case i == 0:
+				field = "Notes"
+			case i <= len(td.rows):
+				field = td.rowNotes(td.rows[i-1], iso)
+			}
+			g.records[i] = slices.Insert(g.records[i], j, field)
+		}
+	}
+	if td.options.Transpose {
+		g = g.transpose()
+		g.records[0][0] = td.periodName()
+	}
+	return g
+}
+
+// Returns the label fields of a total row.
+func (td TimecardData) summaryLabel(label string, describe bool) []string {
+	if describe {
+		return []string{label, ""}
+	}
+	return []string{label}
+}
+
+// Returns the grid with rows and columns swapped.
+func (g grid) transpose() grid {
+	records := make([][]string, len(g.records[0]))
+	for j := range records {
+		records[j] = make([]string, len(g.records))
+		for i, record := range g.records {
+			records[j][i] = record[j]
+		}
+	}
+	return grid{
+		records:      records,
+		labelCols:    1,
+		totalRows:    g.totalCols,
+		totalCols:    g.totalRows,
+		subtotalRows: g.subtotalCols,
+		subtotalCols: g.subtotalRows,
+		dimRows:      g.dimCols,
+		dimCols:      g.dimRows,
+		footnotes:    g.footnotes,
+	}
+}
+
+// Returns the start of the period (column) containing the given date.
+func (td TimecardData) bucket(date time.Time) time.Time {
+	switch td.options.Granularity {
+	case ByWeek:
+		// Weeks start on Monday
+		return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	case ByMonth:
+		y, m, _ := date.Date()
+		return time.Date(y, m, 1, 0, 0, 0, 0, date.Location())
+	default:
+		return date
+	}
+}
+
+// Returns the header label for the period starting on the given date. ISO
+// 8601 labels are used if iso is set.
+func (td TimecardData) columnLabel(col time.Time, iso bool) string {
+	switch td.options.Granularity {
+	case ByWeek:
+		year, week := col.ISOWeek()
+		if iso {
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+		return fmt.Sprintf("W%02d %s", week, col.Format("01/02"))
+	case ByMonth:
+		if iso {
+			return col.Format("2006-01")
+		}
+		return col.Format("Jan 2006")
+	default:
+		if iso {
+			return col.Format(ISODayFormat)
+		}
+		return col.Format(DayFormat)
+	}
+}
+
+// Returns the name of the period covered by each column.
+func (td TimecardData) periodName() string {
+	switch td.options.Granularity {
+	case ByWeek:
+		return "Week"
+	case ByMonth:
+		return "Month"
+	default:
+		return "Date"
+	}
+}
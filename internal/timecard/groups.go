@@ -0,0 +1,171 @@
+package timecard
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// GroupConfigPrefix is the prefix of timewarrior.cfg settings which define
+// tag groups (e.g. `twe.group.ClientA = ^acme-.*`).
+const GroupConfigPrefix = "twe.group."
+
+// GroupPathSeparator separates the levels of nested group names (e.g.
+// `Clients/ClientA`).
+const GroupPathSeparator = "/"
+
+// Separates the elements of a row path in row keys. Rows for plain tags are
+// keyed by the tag itself.
+const rowKeySeparator = "\x1f"
+
+// Group is a named set of tags which are reported as a single timecard row.
+type Group struct {
+	// Group name, split into its nesting levels
+	Path []string
+
+	patterns []*regexp.Regexp
+}
+
+// ParseGroup parses a group definition of the form `Name=pattern[,pattern...]`.
+// Each pattern is a regular expression which must match the whole tag, so
+// plain tag names match exactly. Nested groups are named with
+// GroupPathSeparator (e.g. `Clients/ClientA=^acme-.*`).
+func ParseGroup(definition string) (Group, error) {
+	name, value, ok := strings.Cut(definition, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Group{}, fmt.Errorf("group %q must have the form Name=pattern[,pattern...]", definition)
+	}
+	group := Group{}
+	for _, part := range strings.Split(name, GroupPathSeparator) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return Group{}, fmt.Errorf("group %q has an empty name component", name)
+		}
+		group.Path = append(group.Path, part)
+	}
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		p, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return Group{}, fmt.Errorf("group %s: pattern %s failed to compile as regex: %w", name, pattern, err)
+		}
+		group.patterns = append(group.patterns, p)
+	}
+	if len(group.patterns) == 0 {
+		return Group{}, fmt.Errorf("group %s has no patterns", name)
+	}
+	return group, nil
+}
+
+// Returns true if the tag belongs to the group.
+func (g Group) Match(tag string) bool {
+	return matchAny(tag, g.patterns)
+}
+
+// Returns group definitions from the `twe.group.*` settings in the report
+// configuration, sorted by name.
+func groupsFromConfig(config map[string]string) []string {
+	out := []string{}
+	for key, value := range config {
+		if name, ok := strings.CutPrefix(key, GroupConfigPrefix); ok {
+			out = append(out, name+"="+value)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// grouper maps the tags of an interval onto timecard rows.
+type grouper struct {
+	groups []Group
+
+	// If true, tags are shown as rows under their group
+	showTags bool
+
+	// Separator used to roll up hierarchical tags (e.g. "." for
+	// `acme.web.frontend`). Disabled if empty.
+	separator string
+
+	// Maximum depth of hierarchical tags. Unlimited if zero.
+	depth int
+}
+
+func newGrouper(options TimecardOptions, config map[string]string) (grouper, error) {
+	if options.Ungrouped {
+		return grouper{}, nil
+	}
+	g := grouper{
+		showTags:  options.ShowGroupTags,
+		separator: options.GroupSeparator,
+		depth:     options.GroupDepth,
+	}
+	definitions := append(slices.Clone(options.Groups), groupsFromConfig(config)...)
+	for _, definition := range definitions {
+		group, err := ParseGroup(definition)
+		if err != nil {
+			return grouper{}, err
+		}
+		g.groups = append(g.groups, group)
+	}
+	return g, nil
+}
+
+// Returns the row path of the given tag. Tags belong to the first matching
+// group; tags without a group are split into a hierarchy if a separator is
+// configured, and otherwise form their own row.
+func (g grouper) path(tag string) []string {
+	for _, group := range g.groups {
+		if group.Match(tag) {
+			if g.showTags {
+				return append(slices.Clone(group.Path), tag)
+			}
+			return group.Path
+		}
+	}
+	if g.separator != "" {
+		path := strings.Split(tag, g.separator)
+		if g.depth > 0 && len(path) > g.depth {
+			path = path[:g.depth]
+		}
+		return path
+	}
+	return []string{tag}
+}
+
+// rowShare is a row an interval contributes to, along with the number of the
+// interval's tags which belong to that row.
+type rowShare struct {
+	path []string
+	tags int
+}
+
+// Returns every row the given tags contribute to, including parent groups.
+// Each row is returned only once, along with the number of tags which belong
+// to it, so an interval with several tags in the same group is not counted
+// twice in that group.
+func (g grouper) rows(tags []string) []rowShare {
+	out := []rowShare{}
+	for _, tag := range tags {
+		path := g.path(tag)
+		for i := range path {
+			prefix := path[:i+1]
+			j := slices.IndexFunc(out, func(r rowShare) bool { return slices.Equal(r.path, prefix) })
+			if j < 0 {
+				out = append(out, rowShare{path: prefix, tags: 1})
+			} else {
+				out[j].tags++
+			}
+		}
+	}
+	return out
+}
+
+// Returns the key of the row with the given path.
+func rowKey(path []string) string {
+	return strings.Join(path, rowKeySeparator)
+}
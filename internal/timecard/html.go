@@ -0,0 +1,88 @@
+package timecard
+
+import (
+	"html"
+	"strings"
+)
+
+// Inline styles applied to the HTML table when TimecardOptions.HTMLStyle is set
+var (
+	htmlTableStyle  = "border-collapse: collapse; font-family: sans-serif; font-size: 14px;"
+	htmlHeaderStyle = "border: 1px solid #3a3f4b; padding: 4px 8px; background-color: #f0f2f5; text-align: center;"
+	htmlTagStyle    = "border: 1px solid #3a3f4b; padding: 4px 8px; text-align: left;"
+	htmlCellStyle   = "border: 1px solid #3a3f4b; padding: 4px 8px; text-align: right;"
+	htmlTotalStyle  = "font-weight: bold;"
+	htmlDimStyle    = "color: #8b93a6;"
+)
+
+// StringHTML renders the timecard as a standalone HTML table. If
+// TimecardOptions.HTMLStyle is set, inline CSS is added so the table renders
+// consistently when pasted into emails or wikis.
+func (td TimecardData) StringHTML() (string, error) {
+	return renderHTML(td.grid(false, td.formatCell, td.noteStyle(false)), td.options.HTMLStyle), nil
+}
+
+// Render a grid as an HTML table, optionally with inline CSS. Totals and
+// group subtotals are shown in bold.
+func renderHTML(g grid, inlineCSS bool) string {
+	records := g.records
+	nrows := len(records)
+
+	var builder strings.Builder
+	builder.WriteString("<table" + htmlStyle(inlineCSS, htmlTableStyle) + ">\n")
+	for i, record := range records {
+		switch i {
+		case 0:
+			builder.WriteString("  <thead>\n")
+		case 1:
+			builder.WriteString("  <tbody>\n")
+		}
+		builder.WriteString("    <tr>\n")
+		for j, field := range record {
+			if field == "" {
+				field = EmptyChar
+			}
+			var tag, style string
+			switch {
+			case i == 0:
+				tag, style = "th", htmlHeaderStyle
+			case j == 0:
+				tag, style = "th", htmlTagStyle
+			case j < g.labelCols:
+				tag, style = "td", htmlTagStyle
+			default:
+				tag, style = "td", htmlCellStyle
+			}
+			if i > 0 && (g.isTotal(i, j) || g.isSubtotal(i, j)) {
+				style += " " + htmlTotalStyle
+			} else if g.isDimmed(i, j) {
+				style += " " + htmlDimStyle
+			}
+			attrs := htmlStyle(inlineCSS, style)
+			if i == 0 {
+				attrs = ` scope="col"` + attrs
+			} else if j == 0 {
+				attrs = ` scope="row"` + attrs
+			}
+			builder.WriteString("      <" + tag + attrs + ">" + html.EscapeString(field) + "</" + tag + ">\n")
+		}
+		builder.WriteString("    </tr>\n")
+		switch i {
+		case 0:
+			builder.WriteString("  </thead>\n")
+		case nrows - 1:
+			builder.WriteString("  </tbody>\n")
+		}
+	}
+	builder.WriteString("</table>")
+	return builder.String()
+}
+
+// Returns a style attribute for the given CSS, or an empty string if inline
+// styles are disabled.
+func htmlStyle(inlineCSS bool, css string) string {
+	if !inlineCSS {
+		return ""
+	}
+	return ` style="` + html.EscapeString(css) + `"`
+}
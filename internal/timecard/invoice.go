@@ -0,0 +1,197 @@
+package timecard
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// Shown in place of the rate of items without a configured rate
+const unratedLabel = "no rate"
+
+// Invoice contains the amounts billed to each client over a report. Clients
+// are the top-level rows of the timecard (i.e. top-level groups or ungrouped
+// tags) and line items are the rows nested under them.
+type Invoice struct {
+	// Currency of the amounts. Omitted if not configured.
+	Currency string `json:"currency,omitempty"`
+
+	// Report range. Omitted if the report did not define one.
+	Range *JSONRange `json:"range,omitempty"`
+
+	Clients []InvoiceClient `json:"clients"`
+
+	// Total hours and amount billed to all clients
+	Hours float64 `json:"hours"`
+	Total float64 `json:"total"`
+}
+
+// InvoiceClient contains the line items billed to a single client.
+type InvoiceClient struct {
+	Name  string        `json:"name"`
+	Items []InvoiceItem `json:"items"`
+
+	// Total hours and amount billed to the client
+	Hours    float64 `json:"hours"`
+	Subtotal float64 `json:"subtotal"`
+}
+
+// InvoiceItem is a single line of an invoice.
+type InvoiceItem struct {
+	Description string  `json:"description"`
+	Hours       float64 `json:"hours"`
+	Rate        float64 `json:"rate"`
+	Amount      float64 `json:"amount"`
+
+	// True if no rate is configured for the item, which is then billed at 0
+	Unrated bool `json:"unrated,omitempty"`
+}
+
+// RunInvoice generates an invoice from the report, in the format given by
+// TimecardOptions.OutputFormat (markdown, html or json).
+func RunInvoice(tw *timew.Report, options TimecardOptions) (string, error) {
+	data, err := NewTimecardData(tw, options)
+	if err != nil {
+		return "", fmt.Errorf("generating data: %w", err)
+	}
+	invoice := data.Invoice()
+	switch options.OutputFormat {
+	case "markdown", "md":
+		return invoice.StringMarkdown(), nil
+	case "html":
+		return invoice.StringHTML(options.HTMLStyle), nil
+	case "json":
+		return invoice.StringJSON()
+	default:
+		return "", fmt.Errorf("unrecognized invoice format: %s", options.OutputFormat)
+	}
+}
+
+// Invoice returns the amounts billed in the timecard as an invoice.
+func (td TimecardData) Invoice() Invoice {
+	invoice := Invoice{
+		Currency: td.rates.currency,
+		Clients:  []InvoiceClient{},
+	}
+	if !td.start.IsZero() && !td.end.IsZero() {
+		invoice.Range = &JSONRange{Start: td.start, End: td.end}
+	}
+	for _, row := range td.rows {
+		path := td.path(row)
+		if len(path) != 1 {
+			continue
+		}
+		client := InvoiceClient{Name: path[0], Items: []InvoiceItem{}}
+		for _, item := range td.leaves(row) {
+			rate, ok := td.rates.rate(td.path(item))
+			description := strings.Join(td.path(item)[1:], " / ")
+			if description == "" {
+				description = client.Name
+			}
+			hours := td.rowTotals[item].Hours()
+			client.Items = append(client.Items, InvoiceItem{
+				Description: description,
+				Hours:       hours,
+				Rate:        rate,
+				Amount:      amount(td.rowTotals[item], rate),
+				Unrated:     !ok,
+			})
+			client.Hours += hours
+			client.Subtotal += client.Items[len(client.Items)-1].Amount
+		}
+		invoice.Clients = append(invoice.Clients, client)
+		invoice.Hours += client.Hours
+		invoice.Total += client.Subtotal
+	}
+	return invoice
+}
+
+// Returns the rows without children nested (at any depth) under the given
+// row, or the row itself if it has no children.
+func (td TimecardData) leaves(row string) []string {
+	if !td.subtotals[row] {
+		return []string{row}
+	}
+	out := []string{}
+	for _, child := range td.children(row) {
+		out = append(out, td.leaves(child)...)
+	}
+	return out
+}
+
+// Returns the invoice laid out for rendering, with one subtotal row per
+// client followed by its line items.
+func (inv Invoice) grid() grid {
+	header := []string{"Item", "Hours", "Rate", "Amount"}
+	if inv.Currency != "" {
+		header[3] = "Amount (" + inv.Currency + ")"
+	}
+	g := newGrid(header)
+	for _, client := range inv.Clients {
+		g.subtotalRows[len(g.records)] = true
+		g.records = append(g.records, []string{
+			client.Name, formatHours(client.Hours), "", formatAmount(client.Subtotal),
+		})
+		if len(client.Items) == 1 && client.Items[0].Description == client.Name {
+			// Client is billed as a single item
+			g.records[len(g.records)-1][2] = client.Items[0].rateLabel()
+			continue
+		}
+		for _, item := range client.Items {
+			g.records = append(g.records, []string{
+				"  " + item.Description, formatHours(item.Hours), item.rateLabel(), formatAmount(item.Amount),
+			})
+		}
+	}
+	g.records = append(g.records, []string{"TOTAL", formatHours(inv.Hours), "", formatAmount(inv.Total)})
+	g.totalRows = 1
+	return g
+}
+
+// Returns the rate of the item as shown in the invoice, which marks items
+// without a configured rate.
+func (item InvoiceItem) rateLabel() string {
+	if item.Unrated {
+		return unratedLabel
+	}
+	return formatAmount(item.Rate)
+}
+
+// Format decimal hours with two decimal places.
+func formatHours(hours float64) string {
+	return fmt.Sprintf("%.2f", hours)
+}
+
+// StringMarkdown renders the invoice as a Markdown table.
+func (inv Invoice) StringMarkdown() string {
+	return inv.caption() + renderMarkdown(inv.grid())
+}
+
+// StringHTML renders the invoice as an HTML table, optionally with inline
+// CSS.
+func (inv Invoice) StringHTML(inlineCSS bool) string {
+	return renderHTML(inv.grid(), inlineCSS)
+}
+
+// StringJSON renders the invoice as an indented JSON document.
+func (inv Invoice) StringJSON() (string, error) {
+	b, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Returns a line describing the invoice period, followed by a blank line, or
+// an empty string if the report did not define a range.
+func (inv Invoice) caption() string {
+	if inv.Range == nil {
+		return ""
+	}
+	// The range end is exclusive
+	end := inv.Range.End.Add(-time.Nanosecond)
+	return fmt.Sprintf("**Invoice %s - %s**\n\n", inv.Range.Start.Format(ISODayFormat), end.Format(ISODayFormat))
+}
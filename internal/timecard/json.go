@@ -0,0 +1,342 @@
+package timecard
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the JSON timecard document. It is only
+// incremented for backwards-incompatible changes; new fields may be added
+// without changing the version.
+const JSONSchemaVersion = 1
+
+// JSONTimecard is the document written by `twe timecard --format json`.
+type JSONTimecard struct {
+	// Version of the document schema (see JSONSchemaVersion)
+	SchemaVersion int `json:"schema_version"`
+
+	// Report range. Omitted if the report did not define one.
+	Range *JSONRange `json:"range,omitempty"`
+
+	// Increment (in minutes) up to which each duration was rounded
+	IncrementMinutes int `json:"increment_minutes"`
+
+	// Dates (YYYY-MM-DD) of the columns, in order: every day of the report
+	// range (except days off with WorkdaysOnly), plus any day outside it with
+	// recorded time. If the granularity is week or month, each date is the
+	// first day of the period.
+	Dates []string `json:"dates"`
+
+	// One entry per tag, in display order
+	Rows []JSONRow `json:"rows"`
+
+	// Total time recorded on each date, in the same order as Dates
+	Totals []JSONDuration `json:"totals"`
+
+	// Total time recorded in the report
+	Total JSONDuration `json:"total"`
+
+	// Time recorded in intervals excluded by filters. Only included if
+	// ShowExcluded is set.
+	Excluded *JSONExcluded `json:"excluded,omitempty"`
+
+	// Amounts billed. Only included if ShowAmounts is set.
+	Amounts *JSONAmounts `json:"amounts,omitempty"`
+
+	// Time expected and flex-time balance. Only included if ShowExpected is
+	// set.
+	Expected *JSONExpected `json:"expected,omitempty"`
+
+	// Warnings about the data, e.g. intervals which have been running for
+	// longer than the OpenWarning threshold
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Spans of time recorded by more than one interval
+	Overlaps []JSONOverlap `json:"overlaps,omitempty"`
+
+	// Locked periods which overlap the report, i.e. whose timesheets have
+	// been submitted
+	Submitted []JSONRange `json:"submitted,omitempty"`
+
+	Options JSONOptions `json:"options"`
+}
+
+// JSONRange is the start (inclusive) and end (exclusive) of the report, in
+// RFC 3339 format.
+type JSONRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// JSONRow contains the time recorded for a single tag.
+type JSONRow struct {
+	// Tag (or group) name
+	Tag string `json:"tag"`
+
+	// Names of the groups the row is nested under, followed by Tag
+	Path []string `json:"path"`
+
+	// True if the row is a subtotal of the rows nested under it
+	Subtotal bool `json:"subtotal"`
+
+	// Description of the charge code, or "(unmapped)" for tags without a
+	// charge code. Only included if Mapped is set.
+	Description string `json:"description,omitempty"`
+
+	// Time recorded on each date, in the same order as JSONTimecard.Dates
+	Days []JSONDuration `json:"days"`
+
+	// Total time recorded for the tag
+	Total JSONDuration `json:"total"`
+
+	// Dates (YYYY-MM-DD) on which the tag contains time from a running
+	// interval
+	Running []string `json:"running,omitempty"`
+
+	// Names of the rows collapsed into an "Other" row by the Top or Min
+	// options. Only included for "Other" rows.
+	Collapsed []string `json:"collapsed,omitempty"`
+
+	// Distinct annotations recorded on each date, keyed by date. Only
+	// included if ShowNotes is set.
+	Notes map[string][]string `json:"notes,omitempty"`
+
+	// Hourly rate and amount billed for the tag. Only included if ShowAmounts
+	// is set; Rate is omitted for subtotals and tags without a rate.
+	Rate   *float64 `json:"rate,omitempty"`
+	Amount *float64 `json:"amount,omitempty"`
+}
+
+// JSONDuration is a duration expressed both in whole seconds and in decimal
+// hours.
+type JSONDuration struct {
+	Seconds int64   `json:"seconds"`
+	Hours   float64 `json:"hours"`
+
+	// Percentage of the time recorded on the date (or in the report, for
+	// totals). Only included for the time recorded, with percentage units.
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+// JSONExcluded is the time recorded in intervals excluded by filters.
+type JSONExcluded struct {
+	Intervals int `json:"intervals"`
+	JSONDuration
+}
+
+// JSONAmounts are the amounts billed on each date and in total.
+type JSONAmounts struct {
+	// Currency of the amounts. Omitted if not configured.
+	Currency string `json:"currency,omitempty"`
+
+	// Amount billed on each date, in the same order as JSONTimecard.Dates
+	Dates []float64 `json:"dates"`
+
+	// Amount billed over the whole report
+	Total float64 `json:"total"`
+}
+
+// JSONExpected is the time expected on each date and the difference between
+// the time recorded and the time expected.
+type JSONExpected struct {
+	// Time expected on each date, in the same order as JSONTimecard.Dates
+	Dates []JSONDuration `json:"dates"`
+
+	// Time expected over the whole report
+	Total JSONDuration `json:"total"`
+
+	// Time recorded minus time expected
+	Delta JSONDuration `json:"delta"`
+
+	// Flex-time balance carried into the report from the ledger, and the
+	// balance at the end of the report
+	Carried JSONDuration `json:"carried"`
+	Balance JSONDuration `json:"balance"`
+}
+
+// JSONOverlap is a span of time recorded by two intervals.
+type JSONOverlap struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Tags of the two overlapping intervals
+	Tags [2][]string `json:"tags"`
+}
+
+// JSONOptions are the options used to generate the timecard.
+type JSONOptions struct {
+	Filters         []string `json:"filters"`
+	FilterMode      string   `json:"filter_mode"`
+	Excludes        []string `json:"excludes"`
+	IncludeTotalRow bool     `json:"include_total_row"`
+	IncludeTotalCol bool     `json:"include_total_col"`
+	Units           string   `json:"units"`
+	Granularity     string   `json:"granularity"`
+	Rounding        string   `json:"rounding"`
+	RoundingScope   string   `json:"rounding_scope"`
+	Reconcile       bool     `json:"reconcile"`
+	Allocation      string   `json:"allocation"`
+	OpenPolicy      string   `json:"open_policy"`
+	MergeOverlaps   bool     `json:"merge_overlaps"`
+	Sort            string   `json:"sort"`
+	Top             int      `json:"top"`
+	MinSeconds      int64    `json:"min_seconds"`
+}
+
+func newJSONDuration(d time.Duration) JSONDuration {
+	return JSONDuration{
+		Seconds: int64(d / time.Second),
+		Hours:   d.Hours(),
+	}
+}
+
+// Returns the duration with its percentage of whole if the units are
+// percentages (see TimecardData.whole).
+func (td TimecardData) newJSONShare(d, whole time.Duration) JSONDuration {
+	out := newJSONDuration(d)
+	switch td.options.Units {
+	case UnitsPercentOfDay, UnitsPercentOfTotal:
+		percent := 0.0
+		if whole != 0 {
+			percent = float64(d) / float64(whole) * 100
+		}
+		out.Percent = &percent
+	}
+	return out
+}
+
+// Returns s, or an empty slice if s is nil (so it is encoded as [] rather
+// than null).
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// JSON returns the timecard as a JSONTimecard document.
+func (td TimecardData) JSON() JSONTimecard {
+	out := JSONTimecard{
+		SchemaVersion:    JSONSchemaVersion,
+		IncrementMinutes: td.options.Increment,
+		Dates:            make([]string, len(td.columns)),
+		Rows:             make([]JSONRow, len(td.rows)),
+		Totals:           make([]JSONDuration, len(td.columns)),
+		Warnings:         td.warnings,
+		Options: JSONOptions{
+			Filters:         nonNil(td.options.Filters),
+			FilterMode:      td.options.FilterMode,
+			Excludes:        nonNil(td.options.Excludes),
+			IncludeTotalRow: td.options.IncludeTotalRow,
+			IncludeTotalCol: td.options.IncludeTotalCol,
+			Units:           td.options.Units,
+			Granularity:     td.options.Granularity,
+			Rounding:        td.options.Rounding,
+			RoundingScope:   td.options.RoundingScope,
+			Reconcile:       td.options.Reconcile,
+			Allocation:      td.options.Allocation,
+			OpenPolicy:      td.options.OpenPolicy,
+			MergeOverlaps:   td.options.MergeOverlaps,
+			Sort:            td.options.Sort,
+			Top:             td.options.Top,
+			MinSeconds:      int64(td.options.Min / time.Second),
+		},
+	}
+	if td.options.ShowExcluded {
+		out.Excluded = &JSONExcluded{
+			Intervals:    td.excludedCount,
+			JSONDuration: newJSONDuration(td.excluded),
+		}
+	}
+	if !td.start.IsZero() && !td.end.IsZero() {
+		out.Range = &JSONRange{Start: td.start, End: td.end}
+	}
+
+	for _, o := range td.overlaps {
+		out.Overlaps = append(out.Overlaps, JSONOverlap{
+			Start: o.start,
+			End:   o.end,
+			Tags:  [2][]string{nonNil(o.first), nonNil(o.second)},
+		})
+	}
+	for _, l := range td.submitted {
+		out.Submitted = append(out.Submitted, JSONRange{Start: l.Start, End: l.End})
+	}
+	if td.options.ShowAmounts {
+		out.Amounts = &JSONAmounts{
+			Currency: td.rates.currency,
+			Dates:    make([]float64, len(td.columns)),
+			Total:    td.totalAmount(),
+		}
+		for i, col := range td.columns {
+			out.Amounts.Dates[i] = td.columnAmount(col)
+		}
+	}
+
+	if td.options.ShowExpected {
+		out.Expected = &JSONExpected{
+			Dates:   make([]JSONDuration, len(td.columns)),
+			Total:   newJSONDuration(td.expectedTotal),
+			Delta:   newJSONDuration(td.delta()),
+			Carried: newJSONDuration(td.carried),
+			Balance: newJSONDuration(td.carried + td.delta()),
+		}
+		for i, col := range td.columns {
+			out.Expected.Dates[i] = newJSONDuration(td.expected[col])
+		}
+	}
+
+	var total time.Duration
+	for i, col := range td.columns {
+		out.Dates[i] = col.Format(ISODayFormat)
+		out.Totals[i] = td.newJSONShare(td.totals[col], td.whole(&col))
+		total += td.totals[col]
+	}
+	out.Total = td.newJSONShare(total, total)
+
+	for i, row := range td.rows {
+		days := make([]JSONDuration, len(td.columns))
+		for j, col := range td.columns {
+			days[j] = td.newJSONShare(td.data[row][col], td.whole(&col))
+		}
+		path := td.path(row)
+		out.Rows[i] = JSONRow{
+			Tag:         path[len(path)-1],
+			Path:        path,
+			Subtotal:    td.subtotals[row],
+			Description: td.description(row),
+			Running:     td.runningDates(row),
+			Days:        days,
+			Total:       td.newJSONShare(td.rowTotals[row], td.whole(nil)),
+			Collapsed:   td.others[row].rows,
+		}
+		if td.options.ShowNotes {
+			for _, col := range td.columns {
+				if notes := td.cellNotes(row, col); len(notes) > 0 {
+					if out.Rows[i].Notes == nil {
+						out.Rows[i].Notes = make(map[string][]string)
+					}
+					out.Rows[i].Notes[col.Format(ISODayFormat)] = notes
+				}
+			}
+		}
+		if td.options.ShowAmounts {
+			amount := td.rowAmount(row)
+			out.Rows[i].Amount = &amount
+			if rate, ok := td.rates.rate(path); ok && !td.subtotals[row] {
+				out.Rows[i].Rate = &rate
+			}
+		}
+	}
+	return out
+}
+
+// StringJSON renders the timecard as an indented JSON document (see
+// JSONTimecard).
+func (td TimecardData) StringJSON() (string, error) {
+	b, err := json.MarshalIndent(td.JSON(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
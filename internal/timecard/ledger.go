@@ -0,0 +1,136 @@
+package timecard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LedgerConfig is the timewarrior.cfg setting which defines the path of the
+// flex-time ledger (e.g. `twe.ledger = ~/.timewarrior/flex.ledger`).
+const LedgerConfig = "twe.ledger"
+
+// Ledger records the difference between the time recorded and the time
+// expected (the delta) for each reporting period, so a flex-time balance can
+// be carried from one period to the next.
+//
+// Each line of a ledger file is a single entry of the form:
+//
+//	<start> <end> <delta>
+//
+// where start (inclusive) and end (exclusive) are dates formatted as
+// YYYY-MM-DD and delta is a Go duration (e.g. `1h30m0s` or `-45m0s`).
+type Ledger struct {
+	Entries []LedgerEntry
+}
+
+// LedgerEntry is the delta recorded for a single period.
+type LedgerEntry struct {
+	Start time.Time
+	End   time.Time
+	Delta time.Duration
+}
+
+// ReadLedger reads a ledger file. A file which does not exist yet is read as
+// an empty ledger.
+func ReadLedger(path string) (*Ledger, error) {
+	l := &Ledger{}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected '<start> <end> <delta>'", path, n)
+		}
+		start, err := time.ParseInLocation(ISODayFormat, fields[0], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid start date %s", path, n, fields[0])
+		}
+		end, err := time.ParseInLocation(ISODayFormat, fields[1], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid end date %s", path, n, fields[1])
+		}
+		delta, err := time.ParseDuration(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid delta %s", path, n, fields[2])
+		}
+		l.Entries = append(l.Entries, LedgerEntry{Start: start, End: end, Delta: delta})
+	}
+	return l, scanner.Err()
+}
+
+// Balance returns the sum of the deltas of all periods ending on or before
+// the given date, i.e. the balance carried into a period starting then.
+func (l *Ledger) Balance(before time.Time) time.Duration {
+	var balance time.Duration
+	for _, entry := range l.Entries {
+		if !entry.End.After(before) {
+			balance += entry.Delta
+		}
+	}
+	return balance
+}
+
+// Record the delta of a period, replacing any entry for the same period.
+func (l *Ledger) Record(start, end time.Time, delta time.Duration) {
+	l.Entries = slices.DeleteFunc(l.Entries, func(e LedgerEntry) bool {
+		return e.Start.Equal(start) && e.End.Equal(end)
+	})
+	l.Entries = append(l.Entries, LedgerEntry{Start: start, End: end, Delta: delta})
+	slices.SortFunc(l.Entries, func(a, b LedgerEntry) int { return a.Start.Compare(b.Start) })
+}
+
+// Write the ledger to a file.
+func (l *Ledger) Write(path string) error {
+	var builder strings.Builder
+	for _, e := range l.Entries {
+		fmt.Fprintf(&builder, "%s %s %s\n", e.Start.Format(ISODayFormat), e.End.Format(ISODayFormat), e.Delta)
+	}
+	return os.WriteFile(path, []byte(builder.String()), 0o644)
+}
+
+// Replace a leading `~/` in a path with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// Record the delta of the report in the ledger.
+func (td TimecardData) recordBalance() error {
+	if td.options.Ledger == "" {
+		return fmt.Errorf("no ledger configured: set %s", LedgerConfig)
+	}
+	if td.start.IsZero() || td.end.IsZero() {
+		return errors.New("report range is not defined")
+	}
+	ledger, err := ReadLedger(td.options.Ledger)
+	if err != nil {
+		return err
+	}
+	ledger.Record(td.start, td.end, td.delta())
+	return ledger.Write(td.options.Ledger)
+}
@@ -0,0 +1,39 @@
+package timecard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kgoettler/twe/internal/lock"
+)
+
+// Returns the locked periods which overlap the report, i.e. whose timesheets
+// have been submitted.
+func (td TimecardData) lockedPeriods() []lock.Lock {
+	if td.options.Locks == nil {
+		return nil
+	}
+	start, end := td.start, td.end
+	if start.IsZero() || end.IsZero() {
+		// Without a range, the periods with data are checked
+		if len(td.columns) == 0 {
+			return nil
+		}
+		start = td.columns[0]
+		end = td.columns[len(td.columns)-1].AddDate(0, 0, 1)
+	}
+	return td.options.Locks.Overlapping(start, end)
+}
+
+// Returns a line listing the locked periods which overlap the report, or an
+// empty string if there are none.
+func (td TimecardData) submittedLine() string {
+	if len(td.submitted) == 0 {
+		return ""
+	}
+	periods := make([]string, len(td.submitted))
+	for i, l := range td.submitted {
+		periods[i] = fmt.Sprintf("%s (locked %s)", l, l.Locked.Format(ISODayFormat))
+	}
+	return "Submitted: " + strings.Join(periods, ", ")
+}
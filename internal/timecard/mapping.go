@@ -0,0 +1,183 @@
+package timecard
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// MappingConfig is the timewarrior.cfg setting which defines the path of the
+// charge code mapping file (e.g. `twe.mapping = ~/.timewarrior/codes.map`).
+const MappingConfig = "twe.mapping"
+
+// Mapping maps tags onto the charge codes used to submit timesheets.
+//
+// Each line of a mapping file is a rule of the form:
+//
+//	<pattern> <code> [description]
+//
+// where pattern is a regular expression which must match the whole tag (so
+// plain tag names match exactly) and description is a text/template executed
+// with the fields Tag, Code and Groups (the pattern's submatches, with the
+// whole tag first). Blank lines and lines starting with `#` are ignored. Tags
+// are mapped by the first matching rule.
+type Mapping struct {
+	rules []mappingRule
+}
+
+type mappingRule struct {
+	pattern     *regexp.Regexp
+	code        string
+	description *template.Template
+}
+
+// ReadMapping reads a mapping file.
+func ReadMapping(path string) (Mapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Mapping{}, err
+	}
+	defer file.Close()
+	return ParseMapping(file)
+}
+
+// ParseMapping parses the rules of a mapping file.
+func ParseMapping(r io.Reader) (Mapping, error) {
+	m := Mapping{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return Mapping{}, fmt.Errorf("line %d: expected '<pattern> <code> [description]'", n)
+		}
+		pattern, err := regexp.Compile(`^(?:` + fields[0] + `)$`)
+		if err != nil {
+			return Mapping{}, fmt.Errorf("line %d: pattern %s failed to compile as regex: %w", n, fields[0], err)
+		}
+		rule := mappingRule{pattern: pattern, code: fields[1]}
+		description := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(line, fields[0])), fields[1]))
+		if description != "" {
+			rule.description, err = template.New(fields[1]).Option("missingkey=error").Parse(description)
+			if err != nil {
+				return Mapping{}, fmt.Errorf("line %d: parsing description: %w", n, err)
+			}
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m, scanner.Err()
+}
+
+// Lookup returns the charge code and description of the given tag, or false
+// if the tag is not mapped. An error is returned if the description template
+// fails to execute.
+func (m Mapping) Lookup(tag string) (string, string, bool, error) {
+	for _, rule := range m.rules {
+		groups := rule.pattern.FindStringSubmatch(tag)
+		if groups == nil {
+			continue
+		}
+		if rule.description == nil {
+			return rule.code, "", true, nil
+		}
+		var builder strings.Builder
+		err := rule.description.Execute(&builder, struct {
+			Tag    string
+			Code   string
+			Groups []string
+		}{tag, rule.code, groups})
+		if err != nil {
+			return "", "", false, fmt.Errorf("describing %s: %w", tag, err)
+		}
+		return rule.code, builder.String(), true, nil
+	}
+	return "", "", false, nil
+}
+
+// mapper replaces the tags of each interval with their charge codes, and
+// keeps track of the descriptions of each code and of unmapped tags.
+type mapper struct {
+	mapping Mapping
+
+	// Descriptions of each charge code, in the order they were seen
+	descriptions map[string][]string
+
+	// Tags without a charge code
+	unmapped map[string]bool
+}
+
+func newMapper(options TimecardOptions, config map[string]string) (*mapper, error) {
+	path := options.MappingFile
+	if path == "" {
+		path = expandHome(config[MappingConfig])
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no mapping file configured: set %s", MappingConfig)
+	}
+	mapping, err := ReadMapping(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mapping file: %w", err)
+	}
+	return &mapper{
+		mapping:      mapping,
+		descriptions: make(map[string][]string),
+		unmapped:     make(map[string]bool),
+	}, nil
+}
+
+// Returns the charge codes of the given tags. Unmapped tags are kept as they
+// are, and tags which map to the same code are only returned once.
+func (m *mapper) tags(tags []string) ([]string, error) {
+	out := []string{}
+	for _, tag := range tags {
+		code, description, ok, err := m.mapping.Lookup(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			m.unmapped[tag] = true
+			code = tag
+		} else if description != "" && !slices.Contains(m.descriptions[code], description) {
+			m.descriptions[code] = append(m.descriptions[code], description)
+		}
+		if !slices.Contains(out, code) {
+			out = append(out, code)
+		}
+	}
+	return out, nil
+}
+
+// Returns the description of the given row, or a marker if the row is an
+// unmapped tag.
+func (td TimecardData) description(row string) string {
+	if td.mapper == nil {
+		return ""
+	}
+	path := td.path(row)
+	code := path[len(path)-1]
+	if td.mapper.unmapped[code] {
+		return unmappedMarker
+	}
+	return strings.Join(td.mapper.descriptions[code], "; ")
+}
+
+// Description of rows for tags without a charge code
+const unmappedMarker = "(unmapped)"
+
+// Returns the tags without a charge code, sorted by name.
+func (m *mapper) unmappedTags() []string {
+	out := []string{}
+	for tag := range m.unmapped {
+		out = append(out, tag)
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,94 @@
+package timecard
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StringMarkdown renders the timecard as a GitHub-flavored Markdown pipe
+// table. Duration columns are right-aligned and every column is padded so the
+// table is also readable as plain text.
+func (td TimecardData) StringMarkdown() (string, error) {
+	var builder strings.Builder
+	g := td.grid(false, td.formatCell, td.noteStyle(true))
+	builder.WriteString(renderMarkdown(g))
+	footer := append(g.footnotes, td.footer()...)
+	if len(footer) > 0 {
+		builder.WriteString("\n\n")
+		for _, line := range footer {
+			builder.WriteString("_" + escapeMarkdown(line) + "_  \n")
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+// Render a grid as a Markdown pipe table. Group subtotals are shown in bold.
+func renderMarkdown(g grid) string {
+	records := make([][]string, len(g.records))
+	for i, record := range g.records {
+		records[i] = make([]string, len(record))
+		for j, field := range record {
+			field = escapeMarkdown(field)
+			if field == "" {
+				field = EmptyChar
+			}
+			if (j == 0 && g.subtotalRows[i]) || (i == 0 && g.subtotalCols[j]) {
+				trimmed := strings.TrimLeft(field, " ")
+				field = field[:len(field)-len(trimmed)] + "**" + trimmed + "**"
+			}
+			records[i][j] = field
+		}
+	}
+
+	// Calculate column widths
+	widths := make([]int, len(records[0]))
+	for _, record := range records {
+		for i, field := range record {
+			widths[i] = max(widths[i], lipgloss.Width(field), 3)
+		}
+	}
+
+	var builder strings.Builder
+	for i, record := range records {
+		writeMarkdownRecord(&builder, record, widths, g.labelCols)
+		if i == 0 {
+			// Header separator: label columns left-aligned, others right-aligned
+			separator := make([]string, len(widths))
+			for j, w := range widths {
+				if j < g.labelCols {
+					separator[j] = strings.Repeat("-", w)
+				} else {
+					separator[j] = strings.Repeat("-", w-1) + ":"
+				}
+			}
+			builder.WriteString("| " + strings.Join(separator, " | ") + " |\n")
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func writeMarkdownRecord(builder *strings.Builder, record []string, widths []int, labelCols int) {
+	fields := make([]string, len(record))
+	for i, field := range record {
+		pad := strings.Repeat(" ", widths[i]-lipgloss.Width(field))
+		if i < labelCols {
+			fields[i] = field + pad
+		} else {
+			fields[i] = pad + field
+		}
+	}
+	builder.WriteString("| " + strings.Join(fields, " | ") + " |\n")
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`|`, `\|`,
+	`<`, `\<`,
+	`>`, `\>`,
+)
+
+// Escape characters which would otherwise break a pipe table cell or be
+// interpreted as HTML.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,86 @@
+package timecard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// How notes (i.e. the annotations of each tag/day cell) are laid out in a
+// grid
+type noteStyle int
+
+const (
+	// Notes are left out
+	notesNone noteStyle = iota
+
+	// Cells with notes are marked with a footnote number (e.g. `8 [1]`) and
+	// the notes are listed below the grid
+	notesFootnotes
+
+	// Notes are collected in a column, with one entry per period
+	notesColumn
+)
+
+// Separators between the notes of a cell, and between the notes of each
+// period in a notes column
+const (
+	noteSeparator       = "; "
+	noteColumnSeparator = " | "
+)
+
+// Record the annotation of an interval as a note on the given cell. Each
+// annotation is only recorded once per cell.
+func (td *TimecardData) addNote(row string, col time.Time, annotation string) {
+	annotation = strings.TrimSpace(annotation)
+	if annotation == "" {
+		return
+	}
+	if _, ok := td.notes[row]; !ok {
+		td.notes[row] = make(map[time.Time][]string)
+	}
+	if !slices.Contains(td.notes[row][col], annotation) {
+		td.notes[row][col] = append(td.notes[row][col], annotation)
+	}
+}
+
+// Returns the notes of the given cell, in the order they were recorded.
+// Subtotals have no notes of their own, as they repeat those of the rows
+// nested under them.
+func (td TimecardData) cellNotes(row string, col time.Time) []string {
+	if td.subtotals[row] {
+		return nil
+	}
+	return td.notes[row][col]
+}
+
+// Returns the notes of every period of the given row, for a notes column.
+func (td TimecardData) rowNotes(row string, iso bool) string {
+	entries := []string{}
+	for _, col := range td.columns {
+		if notes := td.cellNotes(row, col); len(notes) > 0 {
+			entries = append(entries, td.columnLabel(col, iso)+": "+strings.Join(notes, noteSeparator))
+		}
+	}
+	return strings.Join(entries, noteColumnSeparator)
+}
+
+// Returns the footnote marking a cell, and the line listing its notes.
+func (td TimecardData) footnote(n int, row string, col time.Time) (string, string) {
+	marker := fmt.Sprintf("[%d]", n)
+	label := strings.Join(td.path(row), GroupPathSeparator)
+	return marker, fmt.Sprintf("%s %s, %s: %s", marker, label, td.columnLabel(col, false), strings.Join(td.cellNotes(row, col), noteSeparator))
+}
+
+// Returns the style in which notes are laid out for the given output format.
+func (td TimecardData) noteStyle(footnotes bool) noteStyle {
+	switch {
+	case !td.options.ShowNotes:
+		return notesNone
+	case footnotes:
+		return notesFootnotes
+	default:
+		return notesColumn
+	}
+}
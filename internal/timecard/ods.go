@@ -0,0 +1,205 @@
+package timecard
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Media type of OpenDocument spreadsheets
+const odsMediaType = "application/vnd.oasis.opendocument.spreadsheet"
+
+// StringODS renders the timecard as an OpenDocument spreadsheet, with one
+// sheet per week for daily timecards (see sheets). Durations are written as
+// numbers (or times, for UnitsHM) in the configured units, and the total row
+// and column as SUM formulas. Percentage units are not supported.
+func (td TimecardData) StringODS() (string, error) {
+	if err := checkSheetUnits(td.options.Units); err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	if err := writeODS(&builder, td.sheets(), td.options.Units); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func writeODS(w io.Writer, sheets []sheet, units string) error {
+	z := zip.NewWriter(w)
+	// The media type must be the first file in the archive, uncompressed
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, odsMediaType); err != nil {
+		return err
+	}
+	for _, p := range []struct {
+		name    string
+		content string
+	}{
+		{"META-INF/manifest.xml", odsManifest},
+		{"content.xml", odsContent(sheets, units)},
+	} {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+const odsManifest = xmlHeader + `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+	`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + odsMediaType + `"/>` +
+	`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+	`</manifest:manifest>`
+
+// Returns the content of the spreadsheet, with a bold, shaded header and
+// durations formatted in the given units.
+func odsContent(sheets []sheet, units string) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<office:document-content` +
+		` xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"` +
+		` xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"` +
+		` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"` +
+		` xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"` +
+		` xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"` +
+		` xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0"` +
+		` xmlns:of="urn:oasis:names:tc:opendocument:xmlns:of:1.2"` +
+		` office:version="1.2">`)
+
+	b.WriteString(`<office:automatic-styles>`)
+	switch units {
+	case UnitsHM:
+		b.WriteString(`<number:time-style style:name="N1" number:truncate-on-overflow="false">` +
+			`<number:hours/><number:text>:</number:text><number:minutes number:style="long"/></number:time-style>`)
+	case UnitsMinutes:
+		b.WriteString(`<number:number-style style:name="N1"><number:number number:decimal-places="0" number:min-integer-digits="1"/></number:number-style>`)
+	default:
+		b.WriteString(`<number:number-style style:name="N1"><number:number number:decimal-places="2" number:min-integer-digits="1"/></number:number-style>`)
+	}
+	b.WriteString(`<style:style style:name="header" style:family="table-cell">` +
+		`<style:table-cell-properties fo:background-color="#d9d9d9"/><style:text-properties fo:font-weight="bold"/></style:style>` +
+		`<style:style style:name="label" style:family="table-cell"/>` +
+		`<style:style style:name="bold" style:family="table-cell"><style:text-properties fo:font-weight="bold"/></style:style>` +
+		`<style:style style:name="value" style:family="table-cell" style:data-style-name="N1"/>` +
+		`<style:style style:name="total" style:family="table-cell" style:data-style-name="N1"><style:text-properties fo:font-weight="bold"/></style:style>`)
+	for i, s := range sheets {
+		for j := range s.labelCols {
+			fmt.Fprintf(&b, `<style:style style:name="co%d-%d" style:family="table-column"><style:table-column-properties style:column-width="%.2fcm"/></style:style>`,
+				i+1, j+1, float64(s.labelWidth(j))*0.2)
+		}
+	}
+	b.WriteString(`</office:automatic-styles>`)
+
+	b.WriteString(`<office:body><office:spreadsheet>`)
+	for i, s := range sheets {
+		fmt.Fprintf(&b, `<table:table table:name="%s">`, escapeXML(s.name))
+		for j := range s.labelCols {
+			fmt.Fprintf(&b, `<table:table-column table:style-name="co%d-%d"/>`, i+1, j+1)
+		}
+		if n := len(s.header) - s.labelCols; n > 0 {
+			fmt.Fprintf(&b, `<table:table-column table:number-columns-repeated="%d"/>`, n)
+		}
+		// Freezing the header requires settings.xml, so the header is marked
+		// as a header row instead
+		b.WriteString(`<table:table-header-rows><table:table-row>`)
+		for _, label := range s.header {
+			b.WriteString(odsString(label, "header"))
+		}
+		b.WriteString(`</table:table-row></table:table-header-rows>`)
+
+		for k, row := range s.rows {
+			labelStyle, valueStyle := "label", "value"
+			if row.subtotal {
+				labelStyle, valueStyle = "bold", "total"
+			}
+			b.WriteString(`<table:table-row>`)
+			for _, label := range row.labels {
+				b.WriteString(odsString(label, labelStyle))
+			}
+			for _, d := range row.values {
+				if d == 0 {
+					b.WriteString(`<table:table-cell/>`)
+					continue
+				}
+				b.WriteString(odsValue(d, units, valueStyle, ""))
+			}
+			if s.totalCol {
+				b.WriteString(odsValue(s.rowTotal(k), units, "total", odsFormula(s.rowTotalRanges(k))))
+			}
+			b.WriteString(`</table:table-row>`)
+		}
+
+		if s.totalRow {
+			b.WriteString(`<table:table-row>`)
+			b.WriteString(odsString("TOTAL", "bold"))
+			for j := 1; j < s.labelCols; j++ {
+				b.WriteString(`<table:table-cell/>`)
+			}
+			for j := range s.valueCols() + boolInt(s.totalCol) {
+				b.WriteString(odsValue(s.columnTotal(j), units, "total", odsFormula(s.columnTotalRanges(j))))
+			}
+			b.WriteString(`</table:table-row>`)
+		}
+		b.WriteString(`</table:table>`)
+	}
+	b.WriteString(`</office:spreadsheet></office:body></office:document-content>`)
+	return b.String()
+}
+
+// Returns a cell containing a string.
+func odsString(value, style string) string {
+	return fmt.Sprintf(`<table:table-cell table:style-name="%s" office:value-type="string"><text:p>%s</text:p></table:table-cell>`, style, escapeXML(value))
+}
+
+// Returns a cell containing a duration, computed by the given formula unless
+// it is empty. Durations are written as times for UnitsHM, and as numbers in
+// the units of sheetValue otherwise.
+func odsValue(d time.Duration, units, style, formula string) string {
+	attrs := fmt.Sprintf(`table:style-name="%s"`, style)
+	if formula != "" {
+		attrs += fmt.Sprintf(` table:formula="%s"`, escapeXML(formula))
+	}
+	if units == UnitsHM {
+		attrs += fmt.Sprintf(` office:value-type="time" office:time-value="%s"`, odsTime(d))
+	} else {
+		attrs += fmt.Sprintf(` office:value-type="float" office:value="%s"`, formatNumber(sheetValue(d, units)))
+	}
+	return fmt.Sprintf(`<table:table-cell %s><text:p>%s</text:p></table:table-cell>`, attrs, formatDuration(d, units))
+}
+
+// Returns a SUM formula of the given ranges, or an empty string if there are
+// none.
+func odsFormula(ranges []cellRange) string {
+	if len(ranges) == 0 {
+		return ""
+	}
+	refs := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.from == r.to {
+			refs[i] = "[." + r.from + "]"
+		} else {
+			refs[i] = "[." + r.from + ":." + r.to + "]"
+		}
+	}
+	return "of:=SUM(" + strings.Join(refs, ";") + ")"
+}
+
+// Format a duration as an ISO 8601 duration (e.g. PT7H45M0S), as used for
+// time values.
+func odsTime(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	s := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%sPT%dH%dM%dS", sign, s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,82 @@
+package timecard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// Policies for intervals which are still running (i.e. have no end)
+const (
+	// Count the interval up to the current time
+	OpenNow = "now"
+
+	// Count the interval up to the current time or the end of the report
+	// range, whichever is earlier
+	OpenRangeEnd = "range"
+
+	// Count the interval up to the current time or the end of the day it
+	// started on, whichever is earlier
+	OpenDayEnd = "day"
+
+	// Leave the interval out
+	OpenExclude = "exclude"
+)
+
+// Marker appended to cells which contain time from a running interval
+const runningMarker = "*"
+
+// Returns the running interval with its end set according to the open
+// interval policy, or false if the interval is left out. A warning is
+// recorded if the interval has been running for longer than the OpenWarning
+// threshold.
+func (td *TimecardData) closeOpen(interval timew.Interval, now time.Time) (timew.Interval, bool) {
+	start := interval.Start.Time
+	if age := now.Sub(start); td.options.OpenWarning > 0 && age > td.options.OpenWarning {
+		td.warnings = append(td.warnings, fmt.Sprintf(
+			"Warning: interval tagged %s has been running since %s (%s)",
+			strings.Join(interval.Tags, " "),
+			start.In(time.Local).Format("2006-01-02 15:04"),
+			formatDurationHM(age)+" h",
+		))
+	}
+
+	end := now
+	switch td.options.OpenPolicy {
+	case OpenRangeEnd:
+		if !td.end.IsZero() {
+			end = minTime(end, td.end)
+		}
+	case OpenDayEnd:
+		end = minTime(end, midnightLocal(start).AddDate(0, 0, 1))
+	case OpenExclude:
+		return interval, false
+	}
+	if !end.After(start) {
+		return interval, false
+	}
+	interval.End = &timew.Datetime{Time: end}
+	return interval, true
+}
+
+// Record that the given cell contains time from a running interval.
+func (td *TimecardData) markRunning(row string, col time.Time) {
+	if _, ok := td.running[row]; !ok {
+		td.running[row] = make(map[time.Time]bool)
+	}
+	td.running[row][col] = true
+}
+
+// Returns the dates (YYYY-MM-DD) on which the given row contains time from a
+// running interval.
+func (td TimecardData) runningDates(row string) []string {
+	out := []string{}
+	for _, col := range td.columns {
+		if td.running[row][col] {
+			out = append(out, col.Format(ISODayFormat))
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package timecard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// UntaggedRow is the row to which the time of intervals without tags is
+// reported.
+const UntaggedRow = "(untagged)"
+
+// overlap is a span of time recorded by two intervals.
+type overlap struct {
+	start time.Time
+	end   time.Time
+
+	// Tags of the overlapping intervals
+	first  []string
+	second []string
+}
+
+// Returns the overlaps between the given intervals, which must be closed and
+// sorted by start. Each interval is compared with every earlier interval
+// which has not ended by the time it starts.
+func findOverlaps(intervals []timew.Interval) []overlap {
+	out := []overlap{}
+	active := []*timew.Interval{}
+	for i := range intervals {
+		interval := &intervals[i]
+		active = slices.DeleteFunc(active, func(a *timew.Interval) bool {
+			return !interval.Start.Time.Before(a.End.Time)
+		})
+		for _, a := range active {
+			out = append(out, overlap{
+				start:  interval.Start.Time,
+				end:    minTime(interval.End.Time, a.End.Time),
+				first:  a.Tags,
+				second: interval.Tags,
+			})
+		}
+		active = append(active, interval)
+	}
+	return out
+}
+
+// Returns the time counted more than once when the given intervals (closed
+// and sorted by start) are added up, i.e. the time which clipOverlap leaves
+// out.
+func overlapExcess(intervals []timew.Interval) time.Duration {
+	var excess time.Duration
+	var covered time.Time
+	for _, interval := range intervals {
+		excess += interval.End.Time.Sub(interval.Start.Time)
+		if clipped, ok := clipOverlap(interval, &covered); ok {
+			excess -= clipped.End.Time.Sub(clipped.Start.Time)
+		}
+	}
+	return excess
+}
+
+// Returns the part of the interval after covered (i.e. the time not yet
+// counted by earlier intervals), or false if it is covered completely.
+// Covered is moved to the end of the interval.
+func clipOverlap(interval timew.Interval, covered *time.Time) (timew.Interval, bool) {
+	end := interval.End.Time
+	if !end.After(*covered) {
+		return interval, false
+	}
+	if interval.Start.Time.Before(*covered) {
+		interval.Start = &timew.Datetime{Time: *covered}
+	}
+	*covered = end
+	return interval, true
+}
+
+// Sort intervals by start.
+func sortByStart(intervals []timew.Interval) {
+	slices.SortStableFunc(intervals, func(a, b timew.Interval) int {
+		return a.Start.Time.Compare(b.Start.Time)
+	})
+}
+
+// Returns a line describing an overlap, for the diagnostics below the
+// timecard.
+func (o overlap) String() string {
+	return fmt.Sprintf(
+		"%s-%s (%s) %s / %s",
+		o.start.In(time.Local).Format("2006-01-02 15:04"),
+		o.end.In(time.Local).Format("15:04"),
+		formatDurationHM(o.end.Sub(o.start)),
+		describeTags(o.first),
+		describeTags(o.second),
+	)
+}
+
+func describeTags(tags []string) string {
+	if len(tags) == 0 {
+		return UntaggedRow
+	}
+	return strings.Join(tags, " ")
+}
+
+// Returns the diagnostics section listing overlapping intervals.
+func (td TimecardData) overlapLines() []string {
+	if len(td.overlaps) == 0 {
+		return nil
+	}
+	summary := "counted more than once in the totals"
+	if td.options.MergeOverlaps {
+		summary = "counted only once in the totals"
+	}
+	lines := []string{fmt.Sprintf("Overlapping intervals: %d (%s %s)", len(td.overlaps), formatDurationHM(overlapExcess(td.intervals)), summary)}
+	for _, o := range td.overlaps {
+		lines = append(lines, "  "+o.String())
+	}
+	return lines
+}
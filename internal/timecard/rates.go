@@ -0,0 +1,163 @@
+package timecard
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateConfigPrefix is the prefix of timewarrior.cfg settings which define
+// hourly rates for tags or groups (e.g. `twe.rate.Clients/Acme = 150`).
+const RateConfigPrefix = "twe.rate."
+
+// CurrencyConfig is the timewarrior.cfg setting which defines the currency of
+// all rates (e.g. `twe.currency = USD`).
+const CurrencyConfig = "twe.currency"
+
+// rates maps rows onto hourly rates.
+type rates struct {
+	// Hourly rate by tag, group name or group path (e.g. `Clients/Acme`)
+	byName map[string]float64
+
+	currency string
+}
+
+func newRates(options TimecardOptions, config map[string]string) (rates, error) {
+	r := rates{
+		byName:   make(map[string]float64),
+		currency: options.Currency,
+	}
+	if r.currency == "" {
+		r.currency = config[CurrencyConfig]
+	}
+	definitions := []string{}
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		if name, ok := strings.CutPrefix(key, RateConfigPrefix); ok {
+			definitions = append(definitions, name+"="+config[key])
+		}
+	}
+	// Rates given as options override those in the configuration
+	definitions = append(definitions, options.Rates...)
+	for _, definition := range definitions {
+		name, value, ok := strings.Cut(definition, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return rates{}, fmt.Errorf("rate %q must have the form Name=amount", definition)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 {
+			return rates{}, fmt.Errorf("rate %s: invalid amount %q", name, value)
+		}
+		r.byName[name] = rate
+	}
+	return r, nil
+}
+
+// Returns the hourly rate of the row with the given path. Rates are looked up
+// by the full path (e.g. `Clients/Acme/acme-web`), then by the row's own name,
+// then by each of its parent groups from the closest up. Returns false if no
+// rate applies.
+func (r rates) rate(path []string) (float64, bool) {
+	if rate, ok := r.byName[strings.Join(path, GroupPathSeparator)]; ok {
+		return rate, true
+	}
+	if rate, ok := r.byName[path[len(path)-1]]; ok {
+		return rate, true
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if rate, ok := r.byName[strings.Join(path[:i], GroupPathSeparator)]; ok {
+			return rate, true
+		}
+		if rate, ok := r.byName[path[i-1]]; ok {
+			return rate, true
+		}
+	}
+	return 0, false
+}
+
+// Returns the amount billed for the given duration at the given hourly rate.
+func amount(d time.Duration, rate float64) float64 {
+	return d.Hours() * rate
+}
+
+// Returns the rows nested directly under the given row.
+func (td TimecardData) children(row string) []string {
+	parent := td.path(row)
+	out := []string{}
+	for _, r := range td.rows {
+		path := td.path(r)
+		if len(path) == len(parent)+1 && slices.Equal(path[:len(parent)], parent) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Returns the amount billed for the row on the given column. If col is nil,
+// returns the amount billed for the row's total. Subtotals are the sum of the
+// amounts of their children, so each child is billed at its own rate, and
+// "Other" rows are the sum of the amounts of the rows collapsed into them.
+func (td TimecardData) amount(row string, col *time.Time) float64 {
+	if other, ok := td.others[row]; ok {
+		if col == nil {
+			return other.total
+		}
+		return other.cols[*col]
+	}
+	if td.subtotals[row] {
+		var total float64
+		for _, child := range td.children(row) {
+			total += td.amount(child, col)
+		}
+		return total
+	}
+	rate, _ := td.rates.rate(td.path(row))
+	if col == nil {
+		return amount(td.rowTotals[row], rate)
+	}
+	return amount(td.data[row][*col], rate)
+}
+
+// Returns the amount billed for the row over the whole report.
+func (td TimecardData) rowAmount(row string) float64 {
+	return td.amount(row, nil)
+}
+
+// Returns the amount billed on the given column, i.e. the sum of the amounts
+// of the top-level rows.
+func (td TimecardData) columnAmount(col time.Time) float64 {
+	var total float64
+	for _, row := range td.rows {
+		if len(td.path(row)) == 1 {
+			total += td.amount(row, &col)
+		}
+	}
+	return total
+}
+
+// Returns the amount billed over the whole report.
+func (td TimecardData) totalAmount() float64 {
+	var total float64
+	for _, row := range td.rows {
+		if len(td.path(row)) == 1 {
+			total += td.rowAmount(row)
+		}
+	}
+	return total
+}
+
+// Returns the label of the amount row and column.
+func (td TimecardData) amountLabel() string {
+	if td.rates.currency == "" {
+		return "AMOUNT"
+	}
+	return "AMOUNT (" + td.rates.currency + ")"
+}
+
+// Format an amount of money with two decimal places.
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
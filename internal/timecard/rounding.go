@@ -0,0 +1,225 @@
+package timecard
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Rounding modes
+const (
+	RoundUp      = "up"
+	RoundDown    = "down"
+	RoundNearest = "nearest"
+
+	// Round to nearest, with ties rounded to the nearest even increment
+	RoundBankers = "bankers"
+)
+
+// Rounding scopes
+const (
+	// Round the time each interval contributes to each day
+	ScopeInterval = "interval"
+
+	// Round each tag/day cell
+	ScopeCell = "cell"
+
+	// Round the total for each tag over each week (Monday to Sunday). Cells
+	// covering more than a week (i.e. months) are rounded on their own.
+	ScopeWeek = "week"
+
+	// Round the total for each tag over the whole report
+	ScopeTotal = "total"
+)
+
+// Returns a function which rounds durations up to the given increment (in
+// minutes).
+func getRoundingFunc(increment int) func(time.Duration) time.Duration {
+	round, _ := getRoundingModeFunc(RoundUp, increment)
+	return round
+}
+
+// Returns a function which rounds durations to the given increment (in
+// minutes) using the given rounding mode.
+func getRoundingModeFunc(mode string, increment int) (func(time.Duration) time.Duration, error) {
+	m := time.Minute * time.Duration(increment)
+	var round func(q, r time.Duration) time.Duration
+	switch mode {
+	case RoundUp:
+		round = func(q, r time.Duration) time.Duration {
+			if r > 0 {
+				q++
+			}
+			return q
+		}
+	case RoundDown:
+		round = func(q, _ time.Duration) time.Duration {
+			return q
+		}
+	case RoundNearest:
+		round = func(q, r time.Duration) time.Duration {
+			if 2*r >= m {
+				q++
+			}
+			return q
+		}
+	case RoundBankers:
+		round = func(q, r time.Duration) time.Duration {
+			if 2*r > m || (2*r == m && q%2 != 0) {
+				q++
+			}
+			return q
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized rounding mode: %s", mode)
+	}
+	return func(d time.Duration) time.Duration {
+		if m <= 0 {
+			return d
+		}
+		return round(d/m, d%m) * m
+	}, nil
+}
+
+// Apply rounding to the aggregated durations according to the rounding
+// scope. Durations are already rounded for ScopeInterval. The daily totals
+// are then made to match the rounded cells.
+func (td *TimecardData) applyRounding(round func(time.Duration) time.Duration) {
+	if td.options.RoundingScope == ScopeInterval {
+		return
+	}
+	m := time.Minute * time.Duration(td.options.Increment)
+	counted := td.countedOnce()
+	switch td.options.RoundingScope {
+	case ScopeCell:
+		for row, col := range td.data {
+			if td.options.Reconcile {
+				td.rowTotals[row] = round(td.rowTotals[row])
+				td.reconcile(col, td.rowTotals[row], m)
+				continue
+			}
+			var total time.Duration
+			for date, d := range col {
+				col[date] = round(d)
+				total += col[date]
+			}
+			td.rowTotals[row] = total
+		}
+	case ScopeWeek:
+		for row, col := range td.data {
+			var total time.Duration
+			for _, week := range td.weeks(col) {
+				rounded := round(sumCol(week))
+				if td.options.Reconcile {
+					td.reconcile(week, rounded, m)
+					maps.Copy(col, week)
+				}
+				total += rounded
+			}
+			td.rowTotals[row] = total
+		}
+	case ScopeTotal:
+		for row, col := range td.data {
+			td.rowTotals[row] = round(td.rowTotals[row])
+			if td.options.Reconcile {
+				td.reconcile(col, td.rowTotals[row], m)
+			}
+		}
+	}
+	cellsRounded := td.options.RoundingScope == ScopeCell || td.options.Reconcile
+	for date, d := range td.totals {
+		switch {
+		case counted[date]:
+			// The total is the sum of the top-level cells
+			var total time.Duration
+			for row, col := range td.data {
+				if len(td.path(row)) == 1 {
+					total += col[date]
+				}
+			}
+			td.totals[date] = total
+		case cellsRounded:
+			td.totals[date] = round(d)
+		}
+	}
+}
+
+// Returns the columns in which every interval is counted in exactly one
+// top-level row, i.e. whose total is the sum of the top-level cells. This is
+// not the case for intervals counted in several tags, or for overlaps which
+// are only counted once in the totals.
+func (td TimecardData) countedOnce() map[time.Time]bool {
+	sums := make(timecardCol)
+	for row, col := range td.data {
+		if len(td.path(row)) == 1 {
+			for date, d := range col {
+				sums[date] += d
+			}
+		}
+	}
+	out := make(map[time.Time]bool)
+	for date, d := range td.totals {
+		out[date] = sums[date] == d
+	}
+	return out
+}
+
+// Returns the cells of col split by the week (starting on Monday) they fall
+// in. Cells are keyed by the start of their period, so cells covering a month
+// fall in a week of their own.
+func (td TimecardData) weeks(col timecardCol) map[time.Time]timecardCol {
+	out := make(map[time.Time]timecardCol)
+	for date, d := range col {
+		week := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		if td.options.Granularity == ByMonth {
+			week = date
+		}
+		if out[week] == nil {
+			out[week] = make(timecardCol)
+		}
+		out[week][date] = d
+	}
+	return out
+}
+
+// Returns the sum of the durations in col.
+func sumCol(col timecardCol) time.Duration {
+	var total time.Duration
+	for _, d := range col {
+		total += d
+	}
+	return total
+}
+
+// Round the durations in col to the increment m such that they add up to
+// total, using the largest remainder method: every duration is rounded down,
+// then the increments left over are given to the durations with the largest
+// remainders.
+func (td *TimecardData) reconcile(col timecardCol, total time.Duration, m time.Duration) {
+	if m <= 0 {
+		return
+	}
+	dates := make([]time.Time, 0, len(col))
+	var sum time.Duration
+	for date, d := range col {
+		dates = append(dates, date)
+		sum += d - d%m
+	}
+	// Largest remainder first, earliest date first for ties
+	slices.SortFunc(dates, func(a, b time.Time) int {
+		if ra, rb := col[a]%m, col[b]%m; ra != rb {
+			return cmp.Compare(rb, ra)
+		}
+		return a.Compare(b)
+	})
+	for _, date := range dates {
+		d := col[date]
+		col[date] = d - d%m
+		if sum < total && d%m > 0 {
+			col[date] += m
+			sum += m
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package timecard
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Orders in which rows are sorted. Rows are always sorted among their
+// siblings, so nested rows stay under their group.
+const (
+	// Alphabetically by name
+	SortName = "name"
+
+	// By total time recorded, largest first
+	SortTotal = "total"
+
+	// In the order the rows were first recorded
+	SortFirstSeen = "first-seen"
+)
+
+// Name of the row into which the rows hidden by TimecardOptions.Top and
+// TimecardOptions.Min are collapsed
+const OtherRow = "Other"
+
+// otherRow records the rows collapsed into an "Other" row and the amounts
+// billed for them, as the "Other" row has no rate of its own.
+type otherRow struct {
+	rows  []string
+	cols  map[time.Time]float64
+	total float64
+}
+
+// Sort the rows in the given order (SortName by default), keeping nested rows
+// under their group. "Other" rows are sorted after their siblings.
+func (td *TimecardData) sortRows(order string) {
+	seen := make(map[string]int, len(td.rows))
+	for i, row := range td.rows {
+		seen[row] = i
+	}
+	var compareSiblings func(a, b string) int
+	switch order {
+	case SortTotal:
+		compareSiblings = func(a, b string) int { return cmp.Compare(td.rowTotals[b], td.rowTotals[a]) }
+	case SortFirstSeen:
+		compareSiblings = func(a, b string) int { return cmp.Compare(seen[a], seen[b]) }
+	default:
+		compareSiblings = func(a, b string) int { return 0 }
+	}
+	slices.SortFunc(td.rows, func(a, b string) int {
+		pa, pb := td.path(a), td.path(b)
+		// Compare the ancestors (or selves) of a and b which are siblings
+		k := 0
+		for k < len(pa) && k < len(pb) && pa[k] == pb[k] {
+			k++
+		}
+		if k == len(pa) || k == len(pb) {
+			// One row is nested under the other
+			return cmp.Compare(len(pa), len(pb))
+		}
+		sa, sb := rowKey(pa[:k+1]), rowKey(pb[:k+1])
+		if c := boolCompare(td.isOther(sa), td.isOther(sb)); c != 0 {
+			return c
+		}
+		if c := compareSiblings(sa, sb); c != 0 {
+			return c
+		}
+		return strings.Compare(pa[k], pb[k])
+	})
+}
+
+// Returns true if the given row collects rows hidden by Top or Min.
+func (td TimecardData) isOther(row string) bool {
+	_, ok := td.others[row]
+	return ok
+}
+
+// Compare booleans, with false first.
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Collapse rows into "Other" rows, so the time recorded for them still adds
+// up to the totals. Only the top top-level rows by total are kept (all if top
+// is zero), and rows below the threshold are collapsed at every level into an
+// "Other" row under their group.
+func (td *TimecardData) collapseRows(top int, threshold time.Duration) {
+	if top <= 0 && threshold <= 0 {
+		return
+	}
+	hidden := make(map[string]bool)
+	if top > 0 {
+		roots := []string{}
+		for _, row := range td.rows {
+			if len(td.path(row)) == 1 {
+				roots = append(roots, row)
+			}
+		}
+		slices.SortStableFunc(roots, func(a, b string) int { return cmp.Compare(td.rowTotals[b], td.rowTotals[a]) })
+		for _, row := range roots[min(top, len(roots)):] {
+			hidden[row] = true
+		}
+	}
+	if threshold > 0 {
+		for _, row := range td.rows {
+			if td.rowTotals[row] < threshold {
+				hidden[row] = true
+			}
+		}
+	}
+
+	rows := []string{}
+	for _, row := range td.rows {
+		path := td.path(row)
+		// Rows nested under a hidden row are collapsed along with it
+		nested := slices.ContainsFunc(td.rows, func(r string) bool {
+			p := td.path(r)
+			return hidden[r] && len(p) < len(path) && slices.Equal(p, path[:len(p)])
+		})
+		if nested {
+			continue
+		}
+		if !hidden[row] {
+			rows = append(rows, row)
+			continue
+		}
+		other := rowKey(append(slices.Clone(path[:len(path)-1]), OtherRow))
+		if !td.isOther(other) {
+			td.paths[other] = append(slices.Clone(path[:len(path)-1]), OtherRow)
+			td.others[other] = otherRow{cols: make(map[time.Time]float64)}
+			td.data[other] = make(timecardCol)
+			rows = append(rows, other)
+		}
+		collapsed := td.others[other]
+		collapsed.rows = append(collapsed.rows, path[len(path)-1])
+		for _, col := range td.columns {
+			td.data[other][col] += td.data[row][col]
+			collapsed.cols[col] += td.amount(row, &col)
+			for _, note := range td.notes[row][col] {
+				td.addNote(other, col, note)
+			}
+			for _, i := range td.sources[row][col] {
+				td.addSource(other, col, i)
+			}
+			if td.running[row][col] {
+				td.markRunning(other, col)
+			}
+		}
+		collapsed.total += td.rowAmount(row)
+		td.others[other] = collapsed
+		td.rowTotals[other] += td.rowTotals[row]
+	}
+	td.rows = rows
+}
+
+// Returns a line listing the rows collapsed into each "Other" row.
+func (td TimecardData) otherLines() []string {
+	lines := []string{}
+	for _, row := range td.rows {
+		if other, ok := td.others[row]; ok {
+			label := strings.Join(td.path(row), " / ")
+			lines = append(lines, fmt.Sprintf("%s: %s", label, strings.Join(other.rows, ", ")))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,289 @@
+package timecard
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sheet is a single sheet of a spreadsheet export (XLSX or ODS) of the
+// timecard. Cells hold durations rather than formatted text, so they can be
+// written as numbers, and totals are written as SUM formulas where the sum
+// matches the timecard's totals.
+type sheet struct {
+	name   string
+	header []string
+
+	// Number of leading columns which label the rows (e.g. tag and
+	// description)
+	labelCols int
+
+	rows []sheetRow
+
+	// Total of each value column, i.e. the TOTAL row of the timecard. These
+	// differ from the sum of the top-level rows if an interval is counted in
+	// several of them (or overlaps are merged).
+	totals []time.Duration
+
+	// If true, the sheet ends with a total column / total row of SUM formulas
+	totalCol bool
+	totalRow bool
+
+	// True if the row totals are the sum of the row's cells. This is not the
+	// case if totals are rounded on their own (rounding scope week or total
+	// without reconciliation), so they are then written as values.
+	cellsSummed bool
+}
+
+// sheetRow is a single row of a sheet.
+type sheetRow struct {
+	labels []string
+	values []time.Duration
+
+	// Total of the row on this sheet
+	total time.Duration
+
+	// True if the row is a subtotal of the rows nested under it
+	subtotal bool
+
+	// True if the row is added up by the total row, i.e. it is a top-level
+	// row. Nested rows are left out, as their groups already include them.
+	summed bool
+}
+
+// cellRange is a range of cells (e.g. B2:H2) in a formula. A single cell
+// has the same start and end.
+type cellRange struct {
+	from, to string
+}
+
+// Returns the sheets of a spreadsheet export of the timecard. Daily timecards
+// are split into one sheet per week (starting on Monday); weekly and monthly
+// timecards are written on a single sheet.
+func (td TimecardData) sheets() []sheet {
+	groups := [][]time.Time{}
+	names := []string{}
+	for _, col := range td.columns {
+		name := "Timecard"
+		if td.options.Granularity == ByDay {
+			monday := col.AddDate(0, 0, -((int(col.Weekday()) + 6) % 7))
+			name = "Week of " + monday.Format(ISODayFormat)
+		}
+		if len(names) == 0 || names[len(names)-1] != name {
+			groups = append(groups, []time.Time{})
+			names = append(names, name)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], col)
+	}
+	if len(groups) == 0 {
+		groups, names = [][]time.Time{{}}, []string{"Timecard"}
+	}
+
+	// Unless the cells add up to the row totals, a row's total on a sheet is
+	// its total over the report if there is a single sheet, or the rounded sum
+	// of its cells on the sheet otherwise (i.e. its rounded total for the
+	// week)
+	cellsSummed := td.options.RoundingScope == ScopeInterval ||
+		td.options.RoundingScope == ScopeCell || td.options.Reconcile
+	round, _ := getRoundingModeFunc(td.options.Rounding, td.options.Increment)
+
+	sheets := make([]sheet, len(groups))
+	for k, columns := range groups {
+		s := sheet{
+			name:        names[k],
+			header:      []string{"Tag"},
+			labelCols:   1,
+			totalCol:    td.options.IncludeTotalCol,
+			totalRow:    td.options.IncludeTotalRow,
+			cellsSummed: cellsSummed,
+		}
+		if td.mapper != nil {
+			s.header = []string{"Code", "Description"}
+			s.labelCols = 2
+		}
+		for _, col := range columns {
+			s.header = append(s.header, td.columnLabel(col, true))
+			s.totals = append(s.totals, td.totals[col])
+		}
+		if s.totalCol {
+			s.header = append(s.header, "TOTAL")
+		}
+		for _, row := range td.rows {
+			r := sheetRow{
+				labels:   []string{td.rowLabel(row)},
+				subtotal: td.subtotals[row],
+				summed:   len(td.path(row)) == 1,
+			}
+			if td.mapper != nil {
+				r.labels = append(r.labels, td.description(row))
+			}
+			for _, col := range columns {
+				r.values = append(r.values, td.data[row][col])
+				r.total += td.data[row][col]
+			}
+			switch {
+			case cellsSummed:
+			case len(groups) == 1:
+				r.total = td.rowTotals[row]
+			default:
+				r.total = round(r.total)
+			}
+			s.rows = append(s.rows, r)
+		}
+		sheets[k] = s
+	}
+	return sheets
+}
+
+// Returns the number of value columns (i.e. periods) of the sheet.
+func (s sheet) valueCols() int {
+	return len(s.header) - s.labelCols - boolInt(s.totalCol)
+}
+
+// Returns the total of row i.
+func (s sheet) rowTotal(i int) time.Duration {
+	return s.rows[i].total
+}
+
+// Returns the total of value column j, or the sum of the column totals if j
+// is the total column.
+func (s sheet) columnTotal(j int) time.Duration {
+	if j < s.valueCols() {
+		return s.totals[j]
+	}
+	var total time.Duration
+	for _, d := range s.totals {
+		total += d
+	}
+	return total
+}
+
+// Returns the sum of the summed rows in value column j, or of the row totals
+// if j is the total column.
+func (s sheet) summedTotal(j int) time.Duration {
+	var total time.Duration
+	for i, row := range s.rows {
+		if !row.summed {
+			continue
+		}
+		if j < s.valueCols() {
+			total += row.values[j]
+		} else {
+			total += s.rowTotal(i)
+		}
+	}
+	return total
+}
+
+// Returns the cells added up by the total column on row i (zero-based, below
+// the header). Returns no ranges if the row total is not the sum of its cells
+// (see cellsSummed), which is then written as a value.
+func (s sheet) rowTotalRanges(i int) []cellRange {
+	if s.valueCols() == 0 || !s.cellsSummed {
+		return nil
+	}
+	return []cellRange{{
+		cellRef(s.labelCols, i+1),
+		cellRef(s.labelCols+s.valueCols()-1, i+1),
+	}}
+}
+
+// Returns the cells added up by the total row in column j (zero-based,
+// after the label columns). Consecutive summed rows are merged into ranges.
+// Returns no ranges if their sum is not the column total (see totals), which
+// is then written as a value.
+func (s sheet) columnTotalRanges(j int) []cellRange {
+	if s.summedTotal(j) != s.columnTotal(j) {
+		return nil
+	}
+	ranges := []cellRange{}
+	start := -1
+	for i := 0; i <= len(s.rows); i++ {
+		summed := i < len(s.rows) && s.rows[i].summed
+		switch {
+		case summed && start < 0:
+			start = i
+		case !summed && start >= 0:
+			ranges = append(ranges, cellRange{
+				cellRef(s.labelCols+j, start+1),
+				cellRef(s.labelCols+j, i),
+			})
+			start = -1
+		}
+	}
+	return ranges
+}
+
+// Returns the A1-style reference of the cell in the given column and row
+// (both zero-based), e.g. B2 for (1, 1).
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+// Returns an error if durations cannot be written in the given units.
+// Percentages are relative to totals which the formulas of a spreadsheet do
+// not follow, so they are not supported.
+func checkSheetUnits(units string) error {
+	switch units {
+	case UnitsPercentOfDay, UnitsPercentOfTotal:
+		return fmt.Errorf("units %s are not supported in spreadsheets", units)
+	}
+	return nil
+}
+
+// Returns the value of a duration in a spreadsheet cell: days for UnitsHM
+// (shown with a [h]:mm format), minutes for UnitsMinutes and hours
+// otherwise.
+func sheetValue(d time.Duration, units string) float64 {
+	switch units {
+	case UnitsHM:
+		return d.Hours() / 24
+	case UnitsMinutes:
+		return d.Minutes()
+	default:
+		return d.Hours()
+	}
+}
+
+// Format a number for a spreadsheet cell.
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Escape text for use in XML character data or attributes.
+func escapeXML(s string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(s))
+	return builder.String()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Returns the width of the label columns in characters, to size them in the
+// spreadsheet.
+func (s sheet) labelWidth(j int) int {
+	width := len(s.header[j])
+	for _, row := range s.rows {
+		width = max(width, len(row.labels[j]))
+	}
+	return width + 2
+}
+
+// Returns the range in A1 notation (e.g. B2:H2), as used in XLSX formulas.
+func (r cellRange) String() string {
+	if r.from == r.to {
+		return r.from
+	}
+	return fmt.Sprintf("%s:%s", r.from, r.to)
+}
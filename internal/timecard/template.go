@@ -0,0 +1,257 @@
+package timecard
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	timew "github.com/kgoettler/twe/pkg/timewarrior"
+)
+
+// TemplateData is the data passed to user-defined report templates (see
+// ReadTemplate). Durations are time.Duration values, to be formatted with the
+// template functions.
+type TemplateData struct {
+	// Start (inclusive) and end (exclusive) of the report range. Zero if the
+	// report did not define one.
+	Start time.Time
+	End   time.Time
+
+	// Periods covered by each column (the first day of each), in order
+	Columns []time.Time
+
+	// One entry per row of the timecard, in display order
+	Rows []TemplateRow
+
+	// Total time recorded in each column, in the same order as Columns
+	Totals []time.Duration
+
+	// Total time recorded in the report
+	Total time.Duration
+
+	// Intervals counted in the timecard, sorted by start time
+	Intervals []TemplateInterval
+
+	// Settings from timewarrior.cfg
+	Config map[string]string
+
+	// Warnings about the data (see TimecardData.footer)
+	Warnings []string
+}
+
+// TemplateRow contains the time recorded for a single row of the timecard.
+type TemplateRow struct {
+	// Tag (or group) name, and the names of the groups the row is nested
+	// under followed by Tag
+	Tag  string
+	Path []string
+
+	// Nesting depth of the row, starting at zero for top-level rows
+	Depth int
+
+	// True if the row is a subtotal of the rows nested under it
+	Subtotal bool
+
+	// Description of the charge code, if Mapped is set
+	Description string
+
+	// Time recorded in each column, in the same order as TemplateData.Columns
+	Cells []time.Duration
+
+	// Total time recorded for the row
+	Total time.Duration
+
+	// Distinct annotations recorded for the row, in order
+	Notes []string
+}
+
+// TemplateInterval is a single interval counted in the timecard.
+type TemplateInterval struct {
+	ID         int
+	Start      time.Time
+	End        time.Time
+	Duration   time.Duration
+	Tags       []string
+	Annotation string
+
+	// True if the interval is still running (End is where it was closed)
+	Running bool
+}
+
+// TemplateGroup is a set of intervals grouped by the `byTag` or `byDate`
+// template functions.
+type TemplateGroup struct {
+	Key       string
+	Intervals []TemplateInterval
+	Total     time.Duration
+}
+
+// ReadTemplate reads a report template. Templates are Go text/templates
+// executed with TemplateData, with the functions:
+//
+//	duration d          format d in the configured units
+//	hours d             decimal hours (e.g. 7.75)
+//	hm d                hours and minutes (e.g. 7:45)
+//	minutes d           whole minutes (e.g. 465)
+//	percent d whole     d as a percentage of whole (e.g. 25%)
+//	date layout t       format t with a Go time layout (e.g. "Jan 2")
+//	isodate t           format t as YYYY-MM-DD
+//	day t               format t as the timecard column header (e.g. Mon 01/02)
+//	byTag intervals     group intervals by tag (intervals with several tags
+//	                    are in several groups)
+//	byDate intervals    group intervals by the date they start on
+//	sum intervals       total duration of intervals
+//	join sep list       join a list of strings
+//	upper s, lower s    change the case of s
+func ReadTemplate(path string, units string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(filepath.Base(path), string(text), units)
+}
+
+// ParseTemplate parses a report template (see ReadTemplate). Durations
+// formatted with the `duration` function are shown in the given units.
+func ParseTemplate(name, text string, units string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs(units)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func templateFuncs(units string) template.FuncMap {
+	return template.FuncMap{
+		"duration": func(d time.Duration) string { return formatDuration(d, units) },
+		"hours":    func(d time.Duration) string { return formatDuration(d, UnitsDecimal) },
+		"hm":       formatDurationHM,
+		"minutes":  formatDurationMinutes,
+		"percent":  formatPercentOf,
+		"date":     func(layout string, t time.Time) string { return t.Format(layout) },
+		"isodate":  func(t time.Time) string { return t.Format(ISODayFormat) },
+		"day":      func(t time.Time) string { return t.Format(DayFormat) },
+		"byTag":    groupIntervalsByTag,
+		"byDate":   groupIntervalsByDate,
+		"sum":      sumIntervals,
+		"join":     func(sep string, list []string) string { return strings.Join(list, sep) },
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+	}
+}
+
+// Group intervals by tag, sorted by tag. Untagged intervals are grouped under
+// UntaggedRow.
+func groupIntervalsByTag(intervals []TemplateInterval) []TemplateGroup {
+	return groupIntervals(intervals, func(interval TemplateInterval) []string {
+		if len(interval.Tags) == 0 {
+			return []string{UntaggedRow}
+		}
+		return interval.Tags
+	})
+}
+
+// Group intervals by the date (YYYY-MM-DD) they start on, in order.
+func groupIntervalsByDate(intervals []TemplateInterval) []TemplateGroup {
+	return groupIntervals(intervals, func(interval TemplateInterval) []string {
+		return []string{interval.Start.Format(ISODayFormat)}
+	})
+}
+
+// Group intervals under each of the keys returned for them, sorted by key.
+func groupIntervals(intervals []TemplateInterval, keys func(TemplateInterval) []string) []TemplateGroup {
+	groups := []TemplateGroup{}
+	for _, interval := range intervals {
+		for _, key := range keys(interval) {
+			i := slices.IndexFunc(groups, func(g TemplateGroup) bool { return g.Key == key })
+			if i < 0 {
+				groups = append(groups, TemplateGroup{Key: key})
+				i = len(groups) - 1
+			}
+			groups[i].Intervals = append(groups[i].Intervals, interval)
+			groups[i].Total += interval.Duration
+		}
+	}
+	slices.SortFunc(groups, func(a, b TemplateGroup) int { return cmp.Compare(a.Key, b.Key) })
+	return groups
+}
+
+// Returns the total duration of the intervals.
+func sumIntervals(intervals []TemplateInterval) time.Duration {
+	var total time.Duration
+	for _, interval := range intervals {
+		total += interval.Duration
+	}
+	return total
+}
+
+// TemplateData returns the timecard as the data passed to report templates.
+func (td TimecardData) TemplateData() TemplateData {
+	out := TemplateData{
+		Start:     td.start,
+		End:       td.end,
+		Columns:   td.columns,
+		Rows:      make([]TemplateRow, len(td.rows)),
+		Totals:    make([]time.Duration, len(td.columns)),
+		Total:     td.total(),
+		Intervals: make([]TemplateInterval, len(td.intervals)),
+		Config:    td.config,
+		Warnings:  td.warnings,
+	}
+	for i, col := range td.columns {
+		out.Totals[i] = td.totals[col]
+	}
+	for i, row := range td.rows {
+		path := td.path(row)
+		cells := make([]time.Duration, len(td.columns))
+		notes := []string{}
+		for j, col := range td.columns {
+			cells[j] = td.data[row][col]
+			for _, note := range td.notes[row][col] {
+				if !slices.Contains(notes, note) {
+					notes = append(notes, note)
+				}
+			}
+		}
+		out.Rows[i] = TemplateRow{
+			Tag:         path[len(path)-1],
+			Path:        path,
+			Depth:       len(path) - 1,
+			Subtotal:    td.subtotals[row],
+			Description: td.description(row),
+			Cells:       cells,
+			Total:       td.rowTotals[row],
+			Notes:       notes,
+		}
+	}
+	for i, interval := range td.intervals {
+		out.Intervals[i] = newTemplateInterval(interval, td.openStarts[interval.Start.Time])
+	}
+	return out
+}
+
+func newTemplateInterval(interval timew.Interval, running bool) TemplateInterval {
+	return TemplateInterval{
+		ID:         interval.ID,
+		Start:      interval.Start.Time,
+		End:        interval.End.Time,
+		Duration:   interval.End.Sub(interval.Start.Time),
+		Tags:       interval.Tags,
+		Annotation: interval.Annotation,
+		Running:    running,
+	}
+}
+
+// StringTemplate renders the timecard with a report template.
+func (td TimecardData) StringTemplate(tmpl *template.Template) (string, error) {
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, td.TemplateData()); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return builder.String(), nil
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/kgoettler/twe/internal/lock"
 	"github.com/kgoettler/twe/internal/styles"
 	timew "github.com/kgoettler/twe/pkg/timewarrior"
 
@@ -15,14 +17,73 @@ import (
 )
 
 var (
-	DayFormat = "Mon 01/02"
-	Day       = time.Hour * 24
-	EmptyChar = "-"
+	DayFormat    = "Mon 01/02"
+	ISODayFormat = "2006-01-02"
+	Day          = time.Hour * 24
+	EmptyChar    = "-"
+)
+
+const (
+	// Decimal hours (e.g. 7.75)
+	UnitsDecimal = "decimal"
+
+	// Hours and minutes (e.g. 7:45)
+	UnitsHM = "hm"
+
+	// Whole minutes (e.g. 465)
+	UnitsMinutes = "minutes"
+
+	// Percentage of the time recorded in the column, i.e. on the day (e.g.
+	// 25%)
+	UnitsPercentOfDay = "percent-of-day"
+
+	// Percentage of the time recorded in the whole report (e.g. 5%)
+	UnitsPercentOfTotal = "percent-of-total"
 )
 
 type TimecardOptions struct {
-	Filters      []string
-	Groups       []string
+	// Regexes matched against tags. If any are given, only intervals with
+	// matching tags are included (see FilterMode).
+	Filters []string
+
+	// Whether an interval must match any (FilterAny, the default) or all
+	// (FilterAll) of the filters to be included
+	FilterMode string
+
+	// Regexes matched against tags. Intervals with a matching tag are
+	// excluded.
+	Excludes []string
+
+	// Regexes matched against annotations. If any are given, only intervals
+	// with a matching annotation are included.
+	AnnotationFilters []string
+
+	// Regexes matched against annotations. Intervals with a matching
+	// annotation are excluded.
+	AnnotationExcludes []string
+
+	// If true, the time excluded by filters is reported below the timecard
+	ShowExcluded bool
+
+	// Group definitions of the form `Name=pattern[,pattern...]` (see
+	// ParseGroup). Groups defined by `twe.group.<Name>` settings in the
+	// report configuration are appended to these.
+	Groups []string
+
+	// If true, tags are shown as rows under their group
+	ShowGroupTags bool
+
+	// If true, every tag forms its own row: Groups, GroupSeparator and the
+	// `twe.group.*` settings are ignored
+	Ungrouped bool
+
+	// Separator used to roll up hierarchical tags (e.g. "." for
+	// `acme.web.frontend`). Disabled if empty.
+	GroupSeparator string
+
+	// Maximum depth to which hierarchical tags are rolled up. Unlimited if zero.
+	GroupDepth int
+
 	OutputFormat string
 	InputFile    string
 
@@ -34,6 +95,122 @@ type TimecardOptions struct {
 
 	// increment (in minutes) up to which each duration will be rounded.
 	Increment int
+
+	// How durations are rounded to the increment (RoundUp by default)
+	Rounding string
+
+	// What is rounded: each interval, each tag/day cell, each tag/week total
+	// or each tag total (ScopeInterval by default)
+	RoundingScope string
+
+	// If true, rounded cells are adjusted so that they add up to the rounded
+	// row totals. Not supported with ScopeInterval.
+	Reconcile bool
+
+	// How the duration of an interval with multiple tags is allocated to its
+	// tags (AllocateFull by default)
+	Allocation string
+
+	// Tags which take priority as the primary tag, in order
+	PrimaryTags []string
+
+	// Regex used to choose the primary tag if none of PrimaryTags is present
+	PrimaryPattern string
+
+	// Units in which durations are displayed (UnitsDecimal by default).
+	// Percentage units only apply to the time recorded; other durations (e.g.
+	// balances) are shown in decimal hours.
+	Units string
+
+	// Period covered by each column (ByDay by default)
+	Granularity string
+
+	// If true, periods are shown as rows and tags as columns
+	Transpose bool
+
+	// If true, HTML output includes inline CSS
+	HTMLStyle bool
+
+	// Width (in cells) into which tables are fitted. Unlimited if zero.
+	Width int
+
+	// If true, time recorded by overlapping intervals is only counted once in
+	// the daily totals
+	MergeOverlaps bool
+
+	// Hourly rates of the form `Name=amount`, where Name is a tag, group name
+	// or group path. Rates defined by `twe.rate.<Name>` settings in the report
+	// configuration are overridden by these.
+	Rates []string
+
+	// Currency of the rates. Defaults to the `twe.currency` setting.
+	Currency string
+
+	// If true, includes a column and row with the amounts billed
+	ShowAmounts bool
+
+	// If true, includes rows with the hours expected, the hours recorded and
+	// the difference between them, and the flex-time balance is reported
+	// below the timecard
+	ShowExpected bool
+
+	// Hours expected each week, spread evenly over Monday to Friday. Defaults
+	// to the `twe.expected.weekly` setting; if neither is set, the working
+	// hours left by the `exclusions.<day>` settings are expected.
+	ExpectedWeekly float64
+
+	// Path of the ledger from which the flex-time balance carried into the
+	// report is read (see Ledger). Defaults to the `twe.ledger` setting.
+	Ledger string
+
+	// If true, the delta of the report is recorded in the ledger
+	RecordBalance bool
+
+	// If true, tags are replaced by their charge codes (see Mapping) before
+	// aggregation, so rows are keyed by charge code
+	Mapped bool
+
+	// Path of the charge code mapping file. Defaults to the `twe.mapping`
+	// setting.
+	MappingFile string
+
+	// How intervals which are still running are counted (OpenNow by default)
+	OpenPolicy string
+
+	// Age above which a running interval is reported with a warning.
+	// Disabled if zero.
+	OpenWarning time.Duration
+
+	// If true, weekends and holidays are only shown if time was recorded on
+	// them
+	WorkdaysOnly bool
+
+	// If true, weekends and holidays are greyed out
+	DimDaysOff bool
+
+	// If true, the annotations of each tag/day cell are collected as notes,
+	// shown as footnotes in tables and Markdown and as a column or field in
+	// CSV, HTML and JSON
+	ShowNotes bool
+
+	// Order in which rows are sorted (SortName by default)
+	Sort string
+
+	// Number of top-level rows to show, by total. The remaining rows are
+	// collapsed into an "Other" row. Unlimited if zero.
+	Top int
+
+	// Rows with a total below this are collapsed into an "Other" row under
+	// their group. Disabled if zero.
+	Min time.Duration
+
+	// Path of a report template (see ReadTemplate). If set, the timecard is
+	// rendered with the template instead of OutputFormat.
+	Template string
+
+	// Locked periods (see lock.Locks). Those which overlap the report are
+	// marked as submitted.
+	Locks *lock.Locks
 }
 
 // TimecardData contains tabular timecard data.
@@ -49,14 +226,71 @@ type TimecardData struct {
 	rows    []string
 	columns []time.Time
 
+	// Path of each row. Rows for plain tags have a single-element path;
+	// grouped rows are nested under their group(s).
+	paths map[string][]string
+
+	// Rows which are subtotals of the rows nested under them
+	subtotals map[string]bool
+
 	// Contains daily totals of hours logged
 	totals timecardCol
 
 	// Contains tag-wise totals of hours logged
 	rowTotals map[string]time.Duration
 
+	// Time recorded in intervals excluded by filters, and the number of
+	// such intervals
+	excluded      time.Duration
+	excludedCount int
+
+	// Cells which contain time from a running interval
+	running map[string]map[time.Time]bool
+
+	// Warnings to report below the timecard
+	warnings []string
+
+	// Time recorded by more than one interval
+	overlaps []overlap
+
+	// Distinct annotations of the intervals recorded in each cell
+	notes map[string]map[time.Time][]string
+
+	// Indices (into intervals) of the intervals recorded in each cell
+	sources map[string]map[time.Time][]int
+
+	// Time expected in each column and in total, and the flex-time balance
+	// carried into the report from the ledger
+	expected      timecardCol
+	expectedTotal time.Duration
+	carried       time.Duration
+
+	// Holidays defined by the `holidays.*` settings
+	holidays map[time.Time]bool
+
+	// "Other" rows and the rows collapsed into them
+	others map[string]otherRow
+
+	// Intervals counted in the timecard, closed according to the open
+	// interval policy, and the start times of those which are still running
+	intervals  []timew.Interval
+	openStarts map[time.Time]bool
+
+	// Settings from the report configuration
+	config map[string]string
+
+	// Start and end of the report range (zero if not defined on the report)
+	start time.Time
+	end   time.Time
+
+	// Locked periods which overlap the report (see lock.Locks)
+	submitted []lock.Lock
+
 	// Options
-	options TimecardOptions
+	options   TimecardOptions
+	allocator allocator
+	rates     rates
+	mapper    *mapper
 
 	round func(d time.Duration) time.Duration
 }
@@ -70,12 +304,39 @@ func Run(tw *timew.Report, options TimecardOptions) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("generating data: %w", err)
 	}
+	if options.RecordBalance {
+		if err := data.recordBalance(); err != nil {
+			return "", fmt.Errorf("recording balance: %w", err)
+		}
+	}
+
+	if options.Template != "" {
+		tmpl, err := ReadTemplate(options.Template, data.options.Units)
+		if err != nil {
+			return "", err
+		}
+		return data.StringTemplate(tmpl)
+	}
 
 	// Get table format
 	var dataString string
 	switch options.OutputFormat {
 	case "table":
 		dataString, err = data.StringTable()
+	case "csv":
+		dataString, err = data.StringCSV(',')
+	case "tsv":
+		dataString, err = data.StringCSV('\t')
+	case "json":
+		dataString, err = data.StringJSON()
+	case "markdown", "md":
+		dataString, err = data.StringMarkdown()
+	case "html":
+		dataString, err = data.StringHTML()
+	case "xlsx":
+		dataString, err = data.StringXLSX()
+	case "ods":
+		dataString, err = data.StringODS()
 	default:
 		return "", fmt.Errorf("unrecognized table format: %s", options.OutputFormat)
 	}
@@ -86,69 +347,241 @@ func Run(tw *timew.Report, options TimecardOptions) (string, error) {
 }
 
 func NewTimecardData(tw *timew.Report, options TimecardOptions) (TimecardData, error) {
+	switch options.Units {
+	case "":
+		options.Units = UnitsDecimal
+	case UnitsDecimal, UnitsHM, UnitsMinutes, UnitsPercentOfDay, UnitsPercentOfTotal:
+	default:
+		return TimecardData{}, fmt.Errorf("unrecognized units: %s", options.Units)
+	}
+	switch options.Granularity {
+	case "":
+		options.Granularity = ByDay
+	case ByDay, ByWeek, ByMonth:
+	default:
+		return TimecardData{}, fmt.Errorf("unrecognized granularity: %s", options.Granularity)
+	}
+	switch options.OpenPolicy {
+	case "":
+		options.OpenPolicy = OpenNow
+	case OpenNow, OpenRangeEnd, OpenDayEnd, OpenExclude:
+	default:
+		return TimecardData{}, fmt.Errorf("unrecognized open interval policy: %s", options.OpenPolicy)
+	}
+	switch options.Sort {
+	case "":
+		options.Sort = SortName
+	case SortName, SortTotal, SortFirstSeen:
+	default:
+		return TimecardData{}, fmt.Errorf("unrecognized sort order: %s", options.Sort)
+	}
+	switch options.RoundingScope {
+	case "":
+		options.RoundingScope = ScopeInterval
+	case ScopeInterval, ScopeCell, ScopeWeek, ScopeTotal:
+	default:
+		return TimecardData{}, fmt.Errorf("unrecognized rounding scope: %s", options.RoundingScope)
+	}
+	if options.Reconcile && options.RoundingScope == ScopeInterval {
+		return TimecardData{}, fmt.Errorf("reconciliation requires a rounding scope other than %s", ScopeInterval)
+	}
+	if options.Rounding == "" {
+		options.Rounding = RoundUp
+	}
+	round, err := getRoundingModeFunc(options.Rounding, options.Increment)
+	if err != nil {
+		return TimecardData{}, err
+	}
+
 	// Localize intervals
 	intervals := localizeIntervals(tw.Intervals)
 
 	// Filter intervals
-	var err error
-	if len(options.Filters) > 0 {
-		intervals, err = filterIntervals(intervals, options.Filters)
+	if options.FilterMode == "" {
+		options.FilterMode = FilterAny
+	}
+	filter, err := newIntervalFilter(options)
+	if err != nil {
+		return TimecardData{}, fmt.Errorf("filtering intervals: %w", err)
+	}
+	intervals, excluded := filter.apply(intervals)
+
+	grouper, err := newGrouper(options, tw.Config)
+	if err != nil {
+		return TimecardData{}, fmt.Errorf("parsing groups: %w", err)
+	}
+	if options.Allocation == "" {
+		options.Allocation = AllocateFull
+	}
+	allocator, err := newAllocator(options)
+	if err != nil {
+		return TimecardData{}, err
+	}
+
+	rates, err := newRates(options, tw.Config)
+	if err != nil {
+		return TimecardData{}, fmt.Errorf("parsing rates: %w", err)
+	}
+
+	var mapper *mapper
+	if options.Mapped {
+		mapper, err = newMapper(options, tw.Config)
 		if err != nil {
-			return TimecardData{}, fmt.Errorf("filtering intervals: %w", err)
+			return TimecardData{}, err
 		}
 	}
 
 	data := TimecardData{
 		data:      make(map[string]map[time.Time]time.Duration),
+		paths:     make(map[string][]string),
+		subtotals: make(map[string]bool),
 		totals:    make(map[time.Time]time.Duration),
 		rowTotals: make(map[string]time.Duration),
+		notes:     make(map[string]map[time.Time][]string),
+		sources:   make(map[string]map[time.Time][]int),
+		running:   make(map[string]map[time.Time]bool),
+		holidays:  holidaysFromConfig(tw.Config),
+		others:    make(map[string]otherRow),
+		config:    tw.Config,
 		options:   options,
-		round:     getRoundingFunc(options.Increment),
+		allocator: allocator,
+		rates:     rates,
+		mapper:    mapper,
+		round:     round,
+	}
+	if options.RoundingScope != ScopeInterval {
+		// Durations are rounded after aggregation
+		data.round = getRoundingFunc(0)
+	}
+	if start, end, err := tw.GetDateRange(); err == nil {
+		data.start = start.Time.Local()
+		data.end = end.Time.Local()
 	}
 
-	// rows := []string{}
-	// columns := []time.Time{}
+	// Close running intervals
+	now := time.Now()
+	closed := make([]timew.Interval, 0, len(intervals))
+	running := make(map[time.Time]bool)
 	for _, interval := range intervals {
-		// Loop over each day this interval overlaps with, calculate the
-		// amount of overlap on that day, and add it to the data structure.
-		var iStart, iEnd, dateCur, dateEnd time.Time
-		iStart = interval.Start.Time
-		if interval.End != nil {
-			iEnd = interval.End.Time
-		} else {
-			iEnd = time.Now()
+		if interval.End == nil {
+			var ok bool
+			if interval, ok = data.closeOpen(interval, now); !ok {
+				continue
+			}
+			running[interval.Start.Time] = true
+		}
+		closed = append(closed, interval)
+	}
+	sortByStart(closed)
+	data.overlaps = findOverlaps(closed)
+	data.intervals, data.openStarts = closed, running
+
+	var covered time.Time
+	for i, interval := range closed {
+		open := running[interval.Start.Time]
+		tags := interval.Tags
+		if mapper != nil {
+			tags, err = mapper.tags(tags)
+			if err != nil {
+				return TimecardData{}, err
+			}
+		}
+		tags = allocator.tags(tags)
+		rows := grouper.rows(tags)
+		if len(tags) == 0 {
+			tags = []string{UntaggedRow}
+			rows = []rowShare{{path: tags, tags: 1}}
 		}
-		dateCur = midnightLocal(iStart)
-		dateEnd = midnightLocal(iEnd)
-		for dateCur.Compare(dateEnd) <= 0 {
-			// overlapStart is midnight of the current day or the interval start time, whichever is later
-			// overlapEnd is midnight of the next day or the interval end time, whichever is earlier
-			overlapStart := maxTime(dateCur, iStart.In(time.Local))
-			overlapEnd := minTime(dateCur.Add(Day), iEnd.In(time.Local))
-			// overlapStart will be before overlapEnd until we've reached a day
-			// with which the interval no longer overlaps.
-			if overlapStart.Before(overlapEnd) {
-				duration := data.round(overlapEnd.Sub(overlapStart))
-				data.AddDateTotal(dateCur, duration)
-				for _, tag := range interval.Tags {
-					data.Add(tag, dateCur, duration)
-					data.AddTagTotal(tag, duration)
+		forEachDay(interval, func(date time.Time, d time.Duration) {
+			duration := data.round(d)
+			column := data.bucket(date)
+			if !options.MergeOverlaps {
+				data.AddDateTotal(column, duration)
+			}
+			for _, row := range rows {
+				key := data.addRow(row.path)
+				share := allocator.share(duration, row.tags, len(tags))
+				data.Add(key, column, share)
+				data.AddTagTotal(key, share)
+				data.addNote(key, column, interval.Annotation)
+				data.addSource(key, column, i)
+				if open {
+					data.markRunning(key, column)
 				}
 			}
-			dateCur = dateCur.Add(Day)
+		})
+		if options.MergeOverlaps {
+			// Only count time not already counted by earlier intervals
+			if clipped, ok := clipOverlap(interval, &covered); ok {
+				forEachDay(clipped, func(date time.Time, d time.Duration) {
+					data.AddDateTotal(data.bucket(date), data.round(d))
+				})
+			}
 		}
 	}
+	for _, interval := range excluded {
+		forEachDay(interval, func(_ time.Time, d time.Duration) {
+			data.excluded += data.round(d)
+		})
+	}
+	data.excludedCount = len(excluded)
 
-	slices.Sort(data.rows)
-	slices.SortFunc(data.columns, func(a, b time.Time) int { return a.Compare(b) })
+	data.applyRounding(round)
+	data.addRangeColumns()
 
-	if len(data.rows) == 0 && len(data.columns) == 0 {
-		return data, fmt.Errorf("no data in range %s - %s", tw.Config["temp.report.start"], tw.Config["temp.report.end"])
+	if options.ShowExpected || options.RecordBalance {
+		expectation, err := newExpectation(options, tw.Config)
+		if err != nil {
+			return TimecardData{}, err
+		}
+		data.addExpected(expectation, time.Now())
+		if data.options.Ledger == "" {
+			data.options.Ledger = expandHome(tw.Config[LedgerConfig])
+		}
+		if data.options.Ledger != "" {
+			ledger, err := ReadLedger(data.options.Ledger)
+			if err != nil {
+				return TimecardData{}, fmt.Errorf("reading ledger: %w", err)
+			}
+			data.carried = ledger.Balance(data.start)
+		}
 	}
 
+	data.collapseRows(options.Top, options.Min)
+	data.sortRows(options.Sort)
+	slices.SortFunc(data.columns, func(a, b time.Time) int { return a.Compare(b) })
+
+	data.submitted = data.lockedPeriods()
+
 	return data, nil
 }
 
+// Register the row with the given path and return its key.
+func (td *TimecardData) addRow(path []string) string {
+	key := rowKey(path)
+	if _, ok := td.paths[key]; !ok {
+		td.paths[key] = path
+		if len(path) > 1 {
+			td.subtotals[rowKey(path[:len(path)-1])] = true
+		}
+	}
+	return key
+}
+
+// Returns the path of the given row.
+func (td TimecardData) path(row string) []string {
+	if path, ok := td.paths[row]; ok {
+		return path
+	}
+	return []string{row}
+}
+
+// Returns the display label of the given row, indented by its depth.
+func (td TimecardData) rowLabel(row string) string {
+	path := td.path(row)
+	return strings.Repeat("  ", len(path)-1) + path[len(path)-1]
+}
+
 // Add time for the given tag + date
 func (td *TimecardData) Add(tag string, date time.Time, duration time.Duration) time.Duration {
 	_, ok := td.data[tag]
@@ -186,69 +619,13 @@ func (td *TimecardData) AddDateTotal(date time.Time, duration time.Duration) {
 	}
 }
 
-func (td TimecardData) Rows() int {
-	// Tag rows + total row
-	var extra int
-	if td.options.IncludeTotalRow {
-		extra++
-	}
-	return len(td.rows) + extra
-}
-
-func (td TimecardData) Columns() int {
-	// Header column + date columns + total column
-	var extra int
-	if td.options.IncludeTotalCol {
-		extra++
-	}
-	return 1 + len(td.columns) + extra
-}
-
-func (td TimecardData) atHeaderColumn(row int) string {
-	rowN := td.Rows() - 1
-	if row == rowN && td.options.IncludeTotalRow {
-		return "TOTAL"
-	}
-	return td.rows[row]
-}
-
-func (td TimecardData) atTotalsColumn(row int) string {
-	if row == td.Rows()-1 && td.options.IncludeTotalRow {
-		return EmptyChar
-	}
-	rowName := td.rows[row]
-	return formatDurationDecimal(td.rowTotals[rowName])
-}
-
-func (td TimecardData) atTotalsRow(cell int) string {
-	if cell == td.Columns()-1 && td.options.IncludeTotalCol {
-		return EmptyChar
-	}
-	return formatDurationDecimal(td.totals[td.columns[cell-1]])
-}
-
-func (td TimecardData) At(row, cell int) string {
-	col0 := 0
-	rowN := td.Rows() - 1
-	colN := td.Columns() - 1
-
-	if cell == col0 {
-		return td.atHeaderColumn(row)
-	}
-
-	if row == rowN && td.options.IncludeTotalRow {
-		return td.atTotalsRow(cell)
-	}
-
-	if cell == colN && td.options.IncludeTotalCol {
-		return td.atTotalsColumn(row)
-	}
-
-	val, err := td.Get(td.rows[row], td.columns[cell-1])
-	if err != nil {
-		return EmptyChar
+// Returns the total time recorded in the report.
+func (td TimecardData) total() time.Duration {
+	var total time.Duration
+	for _, col := range td.columns {
+		total += td.totals[col]
 	}
-	return formatDurationDecimal(val)
+	return total
 }
 
 // Get hours logged for given tag on the given date.
@@ -264,42 +641,84 @@ func (td TimecardData) Get(tag string, date time.Time) (time.Duration, error) {
 	return val, nil
 }
 
+// StringTable renders the timecard as a table. If TimecardOptions.Width is
+// set, the table is fitted into that width (see grid.fit).
 func (td TimecardData) StringTable() (string, error) {
-	// Format table
-	var extra int
-	if td.options.IncludeTotalCol {
-		extra++
+	g := td.grid(false, td.formatCell, td.noteStyle(true))
+	blocks := []string{}
+	for _, block := range g.fit(td.options.Width) {
+		blocks = append(blocks, renderTable(block))
 	}
-	colNames := make([]string, len(td.columns)+extra)
-	for i, col := range td.columns {
-		colNames[i] = col.Format(DayFormat)
-	}
-	if td.options.IncludeTotalCol {
-		colNames[len(colNames)-1] = "TOTAL"
+	ts := strings.Join(blocks, "\n\n")
+	for _, line := range append(g.footnotes, td.footer()...) {
+		ts += "\n" + styles.FooterStyle.Render(line)
 	}
+	return ts, nil
+}
+
+// Render a grid as a table with borders.
+func renderTable(g grid) string {
 	t := tableFormatter.New().
-		Data(td).
-		Headers(append([]string{"Tag"}, colNames...)...).
+		Headers(g.records[0]...).
+		Rows(g.records[1:]...).
 		Border(lipgloss.NormalBorder()).
 		BorderStyle(styles.BorderStyle).
 		StyleFunc(func(row, col int) lipgloss.Style {
 			switch {
-			case row == -1 && col == (td.Columns()-1) && td.options.IncludeTotalCol:
+			case g.cursor != nil && g.cursor.i == row+1 && g.cursor.j == col:
+				return styles.CursorStyle
+			case row == -1 && g.isTotal(0, col):
 				return styles.TotalRowStyle
+			case row == -1 && g.isDimmed(0, col):
+				return styles.DimmedStyle
 			case row == -1:
 				return styles.HeaderStyle
-			case row == (td.Rows()-1) && td.options.IncludeTotalRow:
-				return styles.TotalRowStyle
-			case col == (td.Columns()-1) && td.options.IncludeTotalCol:
+			case g.isTotal(row+1, col):
 				return styles.TotalRowStyle
+			case g.isSubtotal(row+1, col):
+				return styles.SubtotalRowStyle
+			case g.isDimmed(row+1, col):
+				return styles.DimmedStyle
 			case row%2 == 0:
 				return styles.EvenRowStyle
 			default:
 				return styles.OddRowStyle
 			}
 		})
-	ts := t.Render()
-	return ts, nil
+	return t.Render()
+}
+
+// Returns lines to print below the timecard.
+func (td TimecardData) footer() []string {
+	lines := []string{}
+	if submitted := td.submittedLine(); submitted != "" {
+		lines = append(lines, submitted)
+	}
+	lines = append(lines, "Allocation: "+td.allocator.String())
+	if td.options.ShowExcluded {
+		excluded := formatDuration(td.excluded, td.options.Units)
+		if excluded == "" {
+			excluded = "0"
+		}
+		lines = append(lines, fmt.Sprintf("Excluded: %s (%d intervals)", excluded, td.excludedCount))
+	}
+	if len(td.running) > 0 {
+		lines = append(lines, runningMarker+" includes time from a running interval")
+	}
+	lines = append(lines, td.otherLines()...)
+	lines = append(lines, td.warnings...)
+	lines = append(lines, td.overlapLines()...)
+	if td.mapper != nil && len(td.mapper.unmapped) > 0 {
+		lines = append(lines, "Unmapped tags: "+strings.Join(td.mapper.unmappedTags(), ", "))
+	}
+	if td.options.ShowExpected {
+		balance := "Balance: " + formatSigned(td.carried+td.delta(), td.options.Units)
+		if td.options.Ledger != "" {
+			balance += " (carried forward: " + formatSigned(td.carried, td.options.Units) + ")"
+		}
+		lines = append(lines, balance)
+	}
+	return lines
 }
 
 func (td TimecardData) String() string {
@@ -321,28 +740,29 @@ func localizeIntervals(intervals []timew.Interval) []timew.Interval {
 	return out
 }
 
-// Filter out intervals without tags matching at least one of the provided filter regexps.
-func filterIntervals(intervals []timew.Interval, filters []string) ([]timew.Interval, error) {
-	out := []timew.Interval{}
-	filterPatterns := make([]*regexp.Regexp, len(filters))
-	for i, filter := range filters {
-		p, err := regexp.Compile(filter)
-		if err != nil {
-			return out, fmt.Errorf("filter %s failed to compile as regex: %w", filter, err)
-		}
-		filterPatterns[i] = p
-	}
-
-	// Apply filters
-	for _, interval := range intervals {
-		for _, tag := range interval.Tags {
-			if matchAny(tag, filterPatterns) {
-				out = append(out, interval)
-				break
-			}
+// Call fn with the midnight of each day the interval overlaps with and the
+// amount of overlap on that day. Open intervals are extended to the current
+// time.
+func forEachDay(interval timew.Interval, fn func(date time.Time, d time.Duration)) {
+	iStart := interval.Start.Time
+	iEnd := time.Now()
+	if interval.End != nil {
+		iEnd = interval.End.Time
+	}
+	dateCur := midnightLocal(iStart)
+	dateEnd := midnightLocal(iEnd)
+	for dateCur.Compare(dateEnd) <= 0 {
+		// overlapStart is midnight of the current day or the interval start time, whichever is later
+		// overlapEnd is midnight of the next day or the interval end time, whichever is earlier
+		overlapStart := maxTime(dateCur, iStart.In(time.Local))
+		overlapEnd := minTime(dateCur.Add(Day), iEnd.In(time.Local))
+		// overlapStart will be before overlapEnd until we've reached a day
+		// with which the interval no longer overlaps.
+		if overlapStart.Before(overlapEnd) {
+			fn(dateCur, overlapEnd.Sub(overlapStart))
 		}
+		dateCur = dateCur.Add(Day)
 	}
-	return out, nil
 }
 
 func matchAny(s string, patterns []*regexp.Regexp) bool {
@@ -376,31 +796,96 @@ func midnightLocal(t time.Time) time.Time {
 	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
 }
 
-func formatDurationDecimal(d time.Duration) string {
-	dstr := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", d.Hours()), "0"), ".")
-	if dstr == "0" {
+// Format a table cell using the configured units, where whole is the time
+// which percentages are relative to (see whole). Zero durations are shown as
+// EmptyChar.
+func (td TimecardData) formatCell(d, whole time.Duration) string {
+	s := formatShare(d, whole, td.options.Units)
+	if s == "" {
 		return EmptyChar
 	}
-	return dstr
+	return s
 }
 
-func getRoundingFunc(increment int) func(time.Duration) time.Duration {
-	if increment == 0 {
-		return func(d time.Duration) time.Duration {
-			return d
-		}
+// Returns the time which percentages of the given column are relative to, or
+// of the whole report if col is nil.
+func (td TimecardData) whole(col *time.Time) time.Duration {
+	if col != nil && td.options.Units == UnitsPercentOfDay {
+		return td.totals[*col]
 	}
-	m := time.Minute * time.Duration(increment)
-	return func(d time.Duration) time.Duration {
-		if m <= 0 {
-			return d
-		}
-		// Calculate the remainder (modulo)
-		remainder := d % m
-		if remainder == 0 {
-			return d
+	return td.total()
+}
+
+// Format a duration using the given units, as a percentage of whole for
+// percentage units. Durations with a zero whole (e.g. balances) are shown in
+// decimal hours. Zero durations are returned as an empty string.
+func formatShare(d, whole time.Duration, units string) string {
+	switch units {
+	case UnitsPercentOfDay, UnitsPercentOfTotal:
+		if whole == 0 {
+			return formatDuration(d, units)
 		}
-		// Add the difference to round up
-		return d + (m - remainder)
+		return formatPercentOf(d, whole)
+	default:
+		return formatDuration(d, units)
+	}
+}
+
+// Format a duration using the given units. Durations are shown in decimal
+// hours for percentage units. Zero durations are returned as an empty string.
+func formatDuration(d time.Duration, units string) string {
+	switch units {
+	case UnitsHM:
+		return formatDurationHM(d)
+	case UnitsMinutes:
+		return formatDurationMinutes(d)
+	}
+	s := formatDurationDecimal(d)
+	if s == EmptyChar {
+		return ""
 	}
+	return s
+}
+
+// Format a duration as hours and minutes (e.g. 7:45), rounded to the nearest
+// minute.
+func formatDurationHM(d time.Duration) string {
+	m := int64(d.Round(time.Minute) / time.Minute)
+	if m == 0 {
+		return ""
+	}
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, m/60, m%60)
+}
+
+// Format a duration as whole minutes (e.g. 465), rounded to the nearest
+// minute.
+func formatDurationMinutes(d time.Duration) string {
+	m := int64(d.Round(time.Minute) / time.Minute)
+	if m == 0 {
+		return ""
+	}
+	return strconv.FormatInt(m, 10)
+}
+
+// Format a duration as a percentage of whole with up to one decimal (e.g.
+// 12.5%). Returns an empty string if either is zero.
+func formatPercentOf(d, whole time.Duration) string {
+	if d == 0 || whole == 0 {
+		return ""
+	}
+	p := strings.TrimSuffix(fmt.Sprintf("%.1f", float64(d)/float64(whole)*100), ".0")
+	return p + "%"
+}
+
+func formatDurationDecimal(d time.Duration) string {
+	dstr := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", d.Hours()), "0"), ".")
+	if dstr == "0" {
+		return EmptyChar
+	}
+	return dstr
 }
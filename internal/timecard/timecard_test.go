@@ -1,13 +1,23 @@
 package timecard
 
 import (
+	"archive/zip"
 	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/kgoettler/twe/internal/lock"
 	timew "github.com/kgoettler/twe/pkg/timewarrior"
 
+	"github.com/charmbracelet/lipgloss"
+
 	"github.com/stretchr/testify/suite"
 )
 
@@ -22,6 +32,8 @@ func TestTimecardTestSuite(t *testing.T) {
 //go:embed testdata/sample.input
 var sampleInput string
 
+var update = flag.Bool("update", false, "update golden files")
+
 func (suite *TimecardTestSuite) TestReport() {
 	reader := strings.NewReader(sampleInput)
 	tw, err := timew.NewReport(reader)
@@ -41,8 +53,43 @@ func (suite *TimecardTestSuite) TestNewTimecardData_NoIntervals() {
 		startTime,
 		&timew.Datetime{startTime.Add(time.Hour * 24)},
 	)
-	_, err := NewTimecardData(&report, TimecardOptions{})
-	suite.Error(err)
+	data, err := NewTimecardData(&report, TimecardOptions{})
+	suite.Require().NoError(err)
+	suite.Empty(data.rows)
+	suite.Equal([]time.Time{time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)}, data.columns)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_RangeColumns() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # Work
+inc 20260103T140000Z - 20260103T150000Z # Work
+inc 20260106T140000Z - 20260106T150000Z # Work
+`,
+		nil,
+		nil,
+	)
+	report.Config["temp.report.start"] = "20251229T050000Z"
+	report.Config["temp.report.end"] = "20260105T050000Z"
+	report.Config["holidays.en-US.2026_01_01"] = "New Year's Day"
+
+	// Every day of the range is shown, and days outside of it only if they
+	// have data
+	data, err := NewTimecardData(&report, TimecardOptions{DimDaysOff: true})
+	suite.Require().NoError(err)
+	suite.Len(data.columns, 8)
+	g := data.grid(true, data.formatCell, notesNone)
+	suite.Equal([]string{"Work", "-", "-", "-", "1", "-", "1", "-", "1"}, g.records[1])
+	// 01/01 is a holiday, 01/03 and 01/04 are the weekend
+	suite.Equal(map[int]bool{4: true, 6: true, 7: true}, g.dimCols)
+
+	// Days off are only shown if time was recorded on them
+	data, err = NewTimecardData(&report, TimecardOptions{WorkdaysOnly: true})
+	suite.Require().NoError(err)
+	g = data.grid(true, data.formatCell, notesNone)
+	suite.Equal([]string{"Tag", "2025-12-29", "2025-12-30", "2025-12-31", "2026-01-01", "2026-01-02", "2026-01-03", "2026-01-06"}, g.records[0])
+	suite.Empty(g.dimCols)
 }
 
 func (suite *TimecardTestSuite) TestNewTimecardData_OpenInterval() {
@@ -62,6 +109,43 @@ inc 20260101T110000Z # Morning`,
 	suite.Equal(18*time.Hour, val)
 }
 
+func (suite *TimecardTestSuite) TestNewTimecardData_OpenPolicy() {
+	report := getReport(
+		suite.T(),
+		`inc 20260101T050000Z - 20260101T110000Z # Sleep
+inc 20260101T110000Z # Morning`,
+		nil,
+		nil,
+	)
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+
+	// Clipped at the end of the day the interval started on
+	data, err := NewTimecardData(&report, TimecardOptions{OpenPolicy: OpenDayEnd, OpenWarning: 12 * time.Hour})
+	suite.Require().NoError(err)
+	suite.Equal([]time.Time{date}, data.columns)
+	suite.Equal(18*time.Hour, data.rowTotals["Morning"])
+	suite.Equal([]string{"2026-01-01"}, data.runningDates("Morning"))
+	suite.Empty(data.runningDates("Sleep"))
+	g := data.grid(false, data.formatCell, notesNone)
+	suite.Equal([]string{"Morning", "18*"}, g.records[1])
+	suite.Require().Len(data.warnings, 1)
+	suite.Contains(data.warnings[0], "interval tagged Morning has been running since 2026-01-01 06:00")
+
+	// Clipped at the end of the report range (one hour after the start)
+	data, err = NewTimecardData(&report, TimecardOptions{OpenPolicy: OpenRangeEnd})
+	suite.Require().NoError(err)
+	suite.Equal(time.Hour, data.rowTotals["Morning"])
+	suite.Empty(data.warnings)
+
+	data, err = NewTimecardData(&report, TimecardOptions{OpenPolicy: OpenExclude})
+	suite.Require().NoError(err)
+	suite.Equal([]string{"Sleep"}, data.rows)
+	suite.Empty(data.running)
+
+	_, err = NewTimecardData(&report, TimecardOptions{OpenPolicy: "forever"})
+	suite.Error(err)
+}
+
 func (suite *TimecardTestSuite) TestNewTimecardData_WithTotals_Increment() {
 	report := getReport(
 		suite.T(),
@@ -96,8 +180,9 @@ inc 20260101T151005Z - 20260101T155151Z # TASK3
 			TimecardOptions{IncludeTotalRow: true, Increment: tc.increment},
 		)
 		suite.NoError(err)
+		g := data.grid(false, data.formatCell, notesNone)
 		for i, expected := range tc.durations {
-			suite.Equal(expected, data.At(i, 1))
+			suite.Equal(expected, g.records[i+1][1])
 		}
 	}
 }
@@ -120,6 +205,567 @@ inc 20260101T090000Z - 20260101T170000Z # Work
 	suite.Len(data.rows, 1)
 }
 
+func (suite *TimecardTestSuite) TestNewTimecardData_Groups() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # acme-web acme-api
+inc 20260101T150000Z - 20260101T160000Z # globex
+inc 20260101T160000Z - 20260101T170000Z # Admin
+`,
+		nil,
+		nil,
+	)
+	report.Config["twe.group.Clients/Globex"] = "globex"
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+
+	data, err := NewTimecardData(&report, TimecardOptions{Groups: []string{"Clients/ClientA = ^acme-.*"}})
+	suite.Require().NoError(err)
+	suite.Equal([]string{"Admin", "Clients", rowKey([]string{"Clients", "ClientA"}), rowKey([]string{"Clients", "Globex"})}, data.rows)
+	suite.True(data.subtotals["Clients"])
+	suite.Equal("  ClientA", data.rowLabel(data.rows[2]))
+
+	// Interval with two tags in the same group is only counted once
+	val, err := data.Get(rowKey([]string{"Clients", "ClientA"}), date)
+	suite.Require().NoError(err)
+	suite.Equal(time.Hour, val)
+	val, err = data.Get("Clients", date)
+	suite.Require().NoError(err)
+	suite.Equal(2*time.Hour, val)
+
+	data, err = NewTimecardData(&report, TimecardOptions{Groups: []string{"ClientA=acme-web,acme-api"}, ShowGroupTags: true})
+	suite.Require().NoError(err)
+	suite.Equal([]string{
+		"Admin",
+		"ClientA",
+		rowKey([]string{"ClientA", "acme-api"}),
+		rowKey([]string{"ClientA", "acme-web"}),
+		"Clients",
+		rowKey([]string{"Clients", "Globex"}),
+		rowKey([]string{"Clients", "Globex", "globex"}),
+	}, data.rows)
+
+	_, err = NewTimecardData(&report, TimecardOptions{Groups: []string{"ClientA"}})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Rates() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # acme-web
+inc 20260101T150000Z - 20260101T163000Z # acme-api
+inc 20260102T150000Z - 20260102T160000Z # globex
+inc 20260102T160000Z - 20260102T170000Z # Admin
+`,
+		nil,
+		nil,
+	)
+	report.Config["twe.rate.Acme"] = "100"
+	report.Config["twe.rate.globex"] = "80"
+	report.Config["twe.currency"] = "USD"
+	options := TimecardOptions{
+		Groups:        []string{"Acme=^acme-.*"},
+		ShowGroupTags: true,
+		Rates:         []string{"acme-api=120"},
+		ShowAmounts:   true,
+	}
+	data, err := NewTimecardData(&report, options)
+	suite.Require().NoError(err)
+	suite.Equal("AMOUNT (USD)", data.amountLabel())
+
+	// Tags inherit the rate of their group unless they have their own
+	suite.InDelta(100.0, data.rowAmount(rowKey([]string{"Acme", "acme-web"})), 1e-9)
+	suite.InDelta(180.0, data.rowAmount(rowKey([]string{"Acme", "acme-api"})), 1e-9)
+	suite.InDelta(280.0, data.rowAmount("Acme"), 1e-9)
+	suite.InDelta(0.0, data.rowAmount("Admin"), 1e-9)
+	suite.InDelta(360.0, data.totalAmount(), 1e-9)
+	suite.InDelta(80.0, data.columnAmount(time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)), 1e-9)
+
+	g := data.grid(true, func(d, _ time.Duration) string { return formatDuration(d, UnitsDecimal) }, notesNone)
+	suite.Equal(1, g.totalRows)
+	suite.Equal(1, g.totalCols)
+	suite.Equal([]string{"AMOUNT (USD)", "280.00", "80.00", "360.00"}, g.records[len(g.records)-1])
+
+	invoice := data.Invoice()
+	suite.Equal("USD", invoice.Currency)
+	suite.Require().Len(invoice.Clients, 3)
+	suite.Equal("Acme", invoice.Clients[0].Name)
+	suite.Equal([]InvoiceItem{
+		{Description: "acme-api", Hours: 1.5, Rate: 120, Amount: 180},
+		{Description: "acme-web", Hours: 1, Rate: 100, Amount: 100},
+	}, invoice.Clients[0].Items)
+	suite.InDelta(2.5, invoice.Clients[0].Hours, 1e-9)
+	suite.InDelta(280.0, invoice.Clients[0].Subtotal, 1e-9)
+	suite.InDelta(360.0, invoice.Total, 1e-9)
+
+	// Tags without a rate are marked rather than silently billed at 0
+	suite.Equal([]InvoiceItem{{Description: "Admin", Hours: 1, Amount: 0, Unrated: true}}, invoice.Clients[1].Items)
+	suite.Equal(
+		`**Invoice 2026-01-01 - 2026-01-02**
+
+| Item       | Hours |    Rate | Amount (USD) |
+| ---------- | ----: | ------: | -----------: |
+| **Acme**   |  2.50 |       - |       280.00 |
+|   acme-api |  1.50 |  120.00 |       180.00 |
+|   acme-web |  1.00 |  100.00 |       100.00 |
+| **Admin**  |  1.00 | no rate |         0.00 |
+| **globex** |  1.00 |   80.00 |        80.00 |
+| TOTAL      |  4.50 |       - |       360.00 |`,
+		invoice.StringMarkdown(),
+	)
+
+	_, err = NewTimecardData(&report, TimecardOptions{Rates: []string{"acme=lots"}})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Expected() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260105T140000Z - 20260105T220000Z # Work
+inc 20260106T140000Z - 20260106T230000Z # Work
+inc 20260108T140000Z - 20260108T200000Z # Work
+`,
+		nil,
+		nil,
+	)
+	report.Config["temp.report.start"] = "20260105T050000Z"
+	report.Config["temp.report.end"] = "20260110T050000Z"
+	report.Config["holidays.en-US.2026_01_07"] = "Some holiday"
+	ledger := filepath.Join(suite.T().TempDir(), "flex.ledger")
+	suite.Require().NoError(os.WriteFile(ledger, []byte("2025-12-29 2026-01-05 2h0m0s\n"), 0o644))
+
+	options := TimecardOptions{ShowExpected: true, ExpectedWeekly: 40, Ledger: ledger, IncludeTotalCol: true}
+	data, err := NewTimecardData(&report, options)
+	suite.Require().NoError(err)
+
+	// The holiday is not expected; Friday is expected even though nothing
+	// was recorded
+	friday := time.Date(2026, 1, 9, 0, 0, 0, 0, time.Local)
+	suite.Len(data.columns, 5)
+	suite.Equal(friday, data.columns[4])
+	suite.Equal(32*time.Hour, data.expectedTotal)
+	suite.Equal(-9*time.Hour, data.delta())
+	suite.Equal(2*time.Hour, data.carried)
+
+	g := data.grid(true, func(d, _ time.Duration) string { return formatDuration(d, UnitsDecimal) }, notesNone)
+	suite.Equal(3, g.totalRows)
+	suite.Equal([]string{"EXPECTED", "8", "8", "", "8", "8", "32"}, g.records[len(g.records)-3])
+	suite.Equal([]string{"ACTUAL", "8", "9", "", "6", "", "23"}, g.records[len(g.records)-2])
+	suite.Equal([]string{"DELTA", "", "1", "", "-2", "-8", "-9"}, g.records[len(g.records)-1])
+	suite.Contains(data.footer(), "Balance: -7 (carried forward: +2)")
+
+	suite.Require().NoError(data.recordBalance())
+	l, err := ReadLedger(ledger)
+	suite.Require().NoError(err)
+	suite.Len(l.Entries, 2)
+	suite.Equal(-7*time.Hour, l.Balance(time.Date(2026, 1, 12, 0, 0, 0, 0, time.Local)))
+
+	// Working hours are taken from the exclusions if no weekly target is set
+	report.Config["exclusions.monday"] = "<9:00 12:00-12:30 >17:30"
+	data, err = NewTimecardData(&report, TimecardOptions{ShowExpected: true})
+	suite.Require().NoError(err)
+	suite.Equal(8*time.Hour, data.expectedTotal)
+
+	delete(report.Config, "exclusions.monday")
+	_, err = NewTimecardData(&report, TimecardOptions{ShowExpected: true})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Submitted() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260109T140000Z - 20260109T160000Z # Work
+inc 20260112T140000Z - 20260112T160000Z # Work
+`,
+		nil,
+		nil,
+	)
+	data, err := NewTimecardData(&report, TimecardOptions{})
+	suite.Require().NoError(err)
+	suite.Empty(data.submitted)
+	suite.NotContains(data.footer(), "Submitted")
+
+	day := func(d int) time.Time {
+		return time.Date(2026, 1, d, 0, 0, 0, 0, time.Local)
+	}
+	locks := &lock.Locks{}
+	locks.Lock(day(5), day(12), day(12))
+	locks.Lock(day(19), day(26), day(26))
+	data, err = NewTimecardData(&report, TimecardOptions{Locks: locks})
+	suite.Require().NoError(err)
+	suite.Require().Len(data.submitted, 1)
+	suite.Contains(data.footer(), "Submitted: 2026-01-05 to 2026-01-11 (locked 2026-01-12)")
+
+	s, err := data.StringJSON()
+	suite.Require().NoError(err)
+	var doc JSONTimecard
+	suite.Require().NoError(json.Unmarshal([]byte(s), &doc))
+	suite.Require().Len(doc.Submitted, 1)
+	suite.True(doc.Submitted[0].Start.Equal(day(5)))
+}
+
+func (suite *TimecardTestSuite) TestParseMapping() {
+	mapping, err := ParseMapping(strings.NewReader(`
+# pattern   code          description
+acme-web    PRJ-4411-DEV  ACME web development
+acme-(.*)   PRJ-4411-OPS  ACME {{index .Groups 1}}
+globex      PRJ-5000
+`))
+	suite.Require().NoError(err)
+	code, description, ok, err := mapping.Lookup("acme-web")
+	suite.Require().NoError(err)
+	suite.True(ok)
+	suite.Equal("PRJ-4411-DEV", code)
+	suite.Equal("ACME web development", description)
+
+	_, description, _, err = mapping.Lookup("acme-support")
+	suite.Require().NoError(err)
+	suite.Equal("ACME support", description)
+
+	code, description, ok, _ = mapping.Lookup("globex")
+	suite.True(ok)
+	suite.Equal("PRJ-5000", code)
+	suite.Empty(description)
+
+	_, _, ok, _ = mapping.Lookup("globex-api")
+	suite.False(ok)
+
+	_, err = ParseMapping(strings.NewReader("acme-web\n"))
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Mapped() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # acme-web acme-api
+inc 20260101T150000Z - 20260101T160000Z # acme-api
+inc 20260101T160000Z - 20260101T170000Z # Admin
+`,
+		nil,
+		nil,
+	)
+	mapping := filepath.Join(suite.T().TempDir(), "codes.map")
+	suite.Require().NoError(os.WriteFile(mapping, []byte("acme-.* PRJ-4411-DEV ACME {{.Tag}}\n"), 0o644))
+	report.Config["twe.mapping"] = mapping
+
+	data, err := NewTimecardData(&report, TimecardOptions{Mapped: true})
+	suite.Require().NoError(err)
+
+	// Tags which map to the same code are only counted once per interval
+	suite.Equal([]string{"Admin", "PRJ-4411-DEV"}, data.rows)
+	suite.Equal(2*time.Hour, data.rowTotals["PRJ-4411-DEV"])
+	suite.Equal("ACME acme-web; ACME acme-api", data.description("PRJ-4411-DEV"))
+	suite.Equal("(unmapped)", data.description("Admin"))
+	suite.Contains(data.footer(), "Unmapped tags: Admin")
+
+	g := data.grid(true, func(d, _ time.Duration) string { return formatDuration(d, UnitsDecimal) }, notesNone)
+	suite.Equal([]string{"Code", "Description", "2026-01-01"}, g.records[0])
+	suite.Equal([]string{"PRJ-4411-DEV", "ACME acme-web; ACME acme-api", "2"}, g.records[2])
+
+	_, err = NewTimecardData(&report, TimecardOptions{Mapped: true, MappingFile: filepath.Join(suite.T().TempDir(), "missing")})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Notes() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # Work # "Fixed login bug"
+inc 20260101T150000Z - 20260101T160000Z # Work # "Code review"
+inc 20260101T160000Z - 20260101T170000Z # Work # "Fixed login bug"
+inc 20260102T140000Z - 20260102T150000Z # Work
+inc 20260102T150000Z - 20260102T160000Z # Admin # "Expenses"
+`,
+		nil,
+		nil,
+	)
+	data, err := NewTimecardData(&report, TimecardOptions{ShowNotes: true})
+	suite.Require().NoError(err)
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	suite.Equal([]string{"Fixed login bug", "Code review"}, data.cellNotes("Work", date))
+
+	g := data.grid(false, data.formatCell, notesFootnotes)
+	suite.Equal([]string{"Admin", "-", "1 [1]"}, g.records[1])
+	suite.Equal([]string{"Work", "3 [2]", "1"}, g.records[2])
+	suite.Equal([]string{
+		"[1] Admin, Fri 01/02: Expenses",
+		"[2] Work, Thu 01/01: Fixed login bug; Code review",
+	}, g.footnotes)
+
+	g = data.grid(true, func(d, _ time.Duration) string { return formatDuration(d, UnitsDecimal) }, notesColumn)
+	suite.Equal([]string{"Tag", "2026-01-01", "2026-01-02", "Notes"}, g.records[0])
+	suite.Equal([]string{"Work", "3", "1", "2026-01-01: Fixed login bug; Code review"}, g.records[2])
+
+	doc := data.JSON()
+	suite.Equal(map[string][]string{"2026-01-02": {"Expenses"}}, doc.Rows[0].Notes)
+}
+
+func (suite *TimecardTestSuite) TestGridFit() {
+	g := newGrid([]string{"Tag", "Mon 01/05", "Tue 01/06", "Wed 01/07", "TOTAL"})
+	g.records = append(g.records,
+		[]string{"a-very-long-tag-name-for-a-client-project", "8", "7.5", "-", "15.5"},
+		[]string{"Admin", "-", "0.5", "1", "1.5"},
+	)
+	g.totalCols = 1
+
+	// Fits as is
+	suite.Equal([]grid{g}, g.fit(0))
+	suite.Equal([]grid{g}, g.fit(200))
+
+	// Labels are truncated before columns are split
+	blocks := g.fit(75)
+	suite.Require().Len(blocks, 1)
+	suite.Equal("a-very-long-tag-name-for…", blocks[0].records[1][0])
+	suite.LessOrEqual(blocks[0].tableWidth(), 75)
+
+	// Columns are split into blocks which repeat the labels, with the totals
+	// kept in the last block
+	blocks = g.fit(40)
+	suite.Require().Len(blocks, 3)
+	suite.Equal([]string{"Tag", "Mon 01/05"}, blocks[0].records[0])
+	suite.Equal([]string{"Tag", "Tue 01/06"}, blocks[1].records[0])
+	suite.Equal([]string{"Tag", "Wed 01/07", "TOTAL"}, blocks[2].records[0])
+	suite.Equal("a-very-long-…", blocks[0].records[1][0])
+	suite.Equal(0, blocks[0].totalCols)
+	suite.Equal(1, blocks[2].totalCols)
+	for _, block := range blocks {
+		suite.LessOrEqual(block.tableWidth(), 40)
+		suite.LessOrEqual(lipgloss.Width(strings.Split(renderTable(block), "\n")[0]), 40)
+	}
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_UntaggedAndOverlaps() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T160000Z # Work
+inc 20260101T150000Z - 20260101T170000Z # Meeting
+inc 20260101T170000Z - 20260101T180000Z
+`,
+		nil,
+		nil,
+	)
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	data, err := NewTimecardData(&report, TimecardOptions{})
+	suite.Require().NoError(err)
+	suite.Equal([]string{"(untagged)", "Meeting", "Work"}, data.rows)
+	suite.Equal(time.Hour, data.rowTotals[UntaggedRow])
+	suite.Equal(5*time.Hour, data.totals[date])
+	suite.Require().Len(data.overlaps, 1)
+	suite.Equal([]string{
+		"Overlapping intervals: 1 (1:00 counted more than once in the totals)",
+		"  2026-01-01 10:00-11:00 (1:00) Work / Meeting",
+	}, data.overlapLines())
+
+	// Overlapping time is only counted once in the totals, but in every row
+	data, err = NewTimecardData(&report, TimecardOptions{MergeOverlaps: true})
+	suite.Require().NoError(err)
+	suite.Equal(4*time.Hour, data.totals[date])
+	suite.Equal(2*time.Hour, data.rowTotals["Meeting"])
+	suite.Equal(2*time.Hour, data.rowTotals["Work"])
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_NestedOverlaps() {
+	// Both later intervals overlap the first, and each other
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T220000Z # Work
+inc 20260101T150000Z - 20260101T160000Z # Meeting
+inc 20260101T153000Z - 20260101T170000Z # Support
+`,
+		nil,
+		nil,
+	)
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	data, err := NewTimecardData(&report, TimecardOptions{})
+	suite.Require().NoError(err)
+	suite.Equal([]string{
+		"Overlapping intervals: 3 (2:30 counted more than once in the totals)",
+		"  2026-01-01 10:00-11:00 (1:00) Work / Meeting",
+		"  2026-01-01 10:30-12:00 (1:30) Work / Support",
+		"  2026-01-01 10:30-11:00 (0:30) Meeting / Support",
+	}, data.overlapLines())
+
+	// The excess is the time left out when overlaps are merged
+	merged, err := NewTimecardData(&report, TimecardOptions{MergeOverlaps: true})
+	suite.Require().NoError(err)
+	suite.Equal(8*time.Hour, merged.totals[date])
+	suite.Equal(data.totals[date]-merged.totals[date], overlapExcess(data.intervals))
+}
+
+func (suite *TimecardTestSuite) TestCompare() {
+	a := getReport(suite.T(), `
+inc 20260105T140000Z - 20260105T160000Z # Work
+inc 20260106T140000Z - 20260106T150000Z # Admin
+`, nil, nil)
+	a.Config["temp.report.start"] = "20260105T050000Z"
+	a.Config["temp.report.end"] = "20260112T050000Z"
+	b := getReport(suite.T(), `
+inc 20260112T140000Z - 20260112T170000Z # Work
+inc 20260113T140000Z - 20260113T150000Z # Meeting
+`, nil, nil)
+	b.Config["temp.report.start"] = "20260112T050000Z"
+	b.Config["temp.report.end"] = "20260119T050000Z"
+
+	c, err := NewComparison(&a, &b, TimecardOptions{})
+	suite.Require().NoError(err)
+	csv, err := c.StringCSV(',')
+	suite.Require().NoError(err)
+	suite.Equal(
+		"Tag,2026-01-05 - 2026-01-11,2026-01-12 - 2026-01-18,Delta,Delta %,Status\n"+
+			"Admin,1,,-1,-100%,disappeared\n"+
+			"Meeting,,1,+1,,appeared\n"+
+			"Work,2,3,+1,+50%,\n"+
+			"TOTAL,3,4,+1,+33.3%,", csv)
+	suite.Equal([]string{"Appeared: Meeting", "Disappeared: Admin"}, c.footer())
+
+	doc := c.JSON()
+	suite.Require().NotNil(doc.Ranges[0])
+	suite.Require().Len(doc.Rows, 3)
+	suite.Nil(doc.Rows[1].Percent)
+	suite.Equal(Appeared, doc.Rows[1].Status)
+	suite.Equal(JSONDuration{Seconds: 3600, Hours: 1}, doc.Rows[2].Delta)
+	suite.Require().NotNil(doc.Rows[2].Percent)
+	suite.InDelta(50, *doc.Rows[2].Percent, 0.001)
+	suite.Equal(4.0, doc.Totals[1].Hours)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_SortAndCollapse() {
+	report := getReport(suite.T(), `
+inc 20260105T140000Z - 20260105T150000Z # Work
+inc 20260105T150000Z - 20260105T153000Z # Admin
+inc 20260105T153000Z - 20260105T170000Z # Meeting
+inc 20260106T140000Z - 20260106T143000Z # Email
+inc 20260106T143000Z - 20260106T170000Z # Work
+`, nil, nil)
+	report.Config["temp.report.start"] = "20260105T050000Z"
+	report.Config["temp.report.end"] = "20260107T050000Z"
+
+	tcs := []struct {
+		options  TimecardOptions
+		expected []string
+	}{
+		{TimecardOptions{}, []string{"Admin", "Email", "Meeting", "Work"}},
+		{TimecardOptions{Sort: SortTotal}, []string{"Work", "Meeting", "Admin", "Email"}},
+		{TimecardOptions{Sort: SortFirstSeen}, []string{"Work", "Admin", "Meeting", "Email"}},
+		{TimecardOptions{Sort: SortTotal, Top: 2}, []string{"Work", "Meeting", OtherRow}},
+		{TimecardOptions{Min: time.Hour}, []string{"Meeting", "Work", OtherRow}},
+	}
+	for _, tc := range tcs {
+		data, err := NewTimecardData(&report, tc.options)
+		suite.Require().NoError(err)
+		suite.Equal(tc.expected, data.rows)
+	}
+
+	data, err := NewTimecardData(&report, TimecardOptions{Min: time.Hour})
+	suite.Require().NoError(err)
+	suite.Equal(time.Hour, data.rowTotals[OtherRow])
+	suite.Equal(30*time.Minute, data.data[OtherRow][time.Date(2026, 1, 6, 0, 0, 0, 0, time.Local)])
+	suite.Contains(data.footer(), "Other: Admin, Email")
+
+	_, err = NewTimecardData(&report, TimecardOptions{Sort: "size"})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestStringTemplate() {
+	report := getReport(suite.T(), `
+inc 20260101T140000Z - 20260101T163000Z # Work Meeting # "Planning"
+inc 20260102T140000Z - 20260102T150000Z # Work
+`, nil, nil)
+	data, err := NewTimecardData(&report, TimecardOptions{Units: UnitsHM})
+	suite.Require().NoError(err)
+
+	tmpl, err := ParseTemplate("standup", `{{range .Rows}}{{.Tag}} {{duration .Total}} {{percent .Total $.Total}}{{range .Notes}} ({{.}}){{end}}
+{{end}}{{range byDate .Intervals}}{{.Key}}: {{hours .Total}}
+{{end}}{{range byTag .Intervals}}{{upper .Key}}={{minutes (sum .Intervals)}} {{end}}`, data.options.Units)
+	suite.Require().NoError(err)
+	out, err := data.StringTemplate(tmpl)
+	suite.Require().NoError(err)
+	suite.Equal("Meeting 2:30 71.4% (Planning)\n"+
+		"Work 3:30 100% (Planning)\n"+
+		"2026-01-01: 2.5\n"+
+		"2026-01-02: 1\n"+
+		"MEETING=150 WORK=210 ", out)
+
+	_, err = ParseTemplate("broken", "{{range .Rows}", UnitsDecimal)
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_GroupSeparator() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # acme.web.frontend
+inc 20260101T150000Z - 20260101T160000Z # acme.web.backend
+inc 20260101T160000Z - 20260101T170000Z # acme.ops
+`,
+		nil,
+		nil,
+	)
+	data, err := NewTimecardData(&report, TimecardOptions{GroupSeparator: "."})
+	suite.Require().NoError(err)
+	suite.Len(data.rows, 5)
+	suite.Equal(3*time.Hour, data.rowTotals["acme"])
+	suite.Equal(2*time.Hour, data.rowTotals[rowKey([]string{"acme", "web"})])
+
+	data, err = NewTimecardData(&report, TimecardOptions{GroupSeparator: ".", GroupDepth: 2})
+	suite.Require().NoError(err)
+	suite.Equal([]string{"acme", rowKey([]string{"acme", "ops"}), rowKey([]string{"acme", "web"})}, data.rows)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Excludes() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T000000Z - 20260101T060000Z # Sleep
+inc 20260101T090000Z - 20260101T120000Z # Work ClientA # "Sprint planning"
+inc 20260101T120000Z - 20260101T130000Z # Work Lunch
+inc 20260101T130000Z - 20260101T170000Z # Work ClientB # "Code review"
+`,
+		nil,
+		nil,
+	)
+	tcs := []struct {
+		options  TimecardOptions
+		rows     []string
+		excluded time.Duration
+	}{
+		{TimecardOptions{Filters: []string{"Work"}, Excludes: []string{"Lunch"}}, []string{"ClientA", "ClientB", "Work"}, 7 * time.Hour},
+		{TimecardOptions{Filters: []string{"Work", "^Client"}, FilterMode: FilterAll}, []string{"ClientA", "ClientB", "Work"}, 7 * time.Hour},
+		{TimecardOptions{Filters: []string{"Sleep", "Lunch"}}, []string{"Lunch", "Sleep", "Work"}, 7 * time.Hour},
+		{TimecardOptions{AnnotationFilters: []string{"(?i)review"}}, []string{"ClientB", "Work"}, 10 * time.Hour},
+		{TimecardOptions{AnnotationExcludes: []string{"planning"}, Excludes: []string{"Sleep"}}, []string{"ClientB", "Lunch", "Work"}, 9 * time.Hour},
+	}
+	for _, tc := range tcs {
+		tc.options.ShowExcluded = true
+		data, err := NewTimecardData(&report, tc.options)
+		suite.Require().NoError(err)
+		suite.Equal(tc.rows, data.rows, "%+v", tc.options)
+		suite.Equal(tc.excluded, data.excluded, "%+v", tc.options)
+
+		// Included + excluded time adds up to the unfiltered total
+		var total time.Duration
+		for _, d := range data.totals {
+			total += d
+		}
+		suite.Equal(14*time.Hour, total+data.excluded)
+	}
+
+	data, err := NewTimecardData(&report, TimecardOptions{Excludes: []string{"Sleep"}, ShowExcluded: true})
+	suite.Require().NoError(err)
+	suite.Contains(data.footer(), "Excluded: 6 (1 intervals)")
+
+	_, err = NewTimecardData(&report, TimecardOptions{FilterMode: "some"})
+	suite.Error(err)
+	_, err = NewTimecardData(&report, TimecardOptions{Excludes: []string{"("}})
+	suite.Error(err)
+}
+
 func (suite *TimecardTestSuite) TestGet_NoDataForTag() {
 	report := getReport(
 		suite.T(),
@@ -177,6 +823,381 @@ func (suite *TimecardTestSuite) TestFormatDurationDecimal() {
 	}
 }
 
+func (suite *TimecardTestSuite) TestFormatDurationHM() {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, ""},
+		{15 * time.Minute, "0:15"},
+		{7*time.Hour + 45*time.Minute, "7:45"},
+		{30*time.Hour + 5*time.Minute + 40*time.Second, "30:06"},
+	}
+	for _, tc := range testCases {
+		suite.Equal(tc.expected, formatDurationHM(tc.duration),
+			"duration: %v", tc.duration)
+	}
+}
+
+func (suite *TimecardTestSuite) TestFormatShare() {
+	testCases := []struct {
+		duration time.Duration
+		whole    time.Duration
+		units    string
+		expected string
+	}{
+		{7*time.Hour + 45*time.Minute, 0, UnitsMinutes, "465"},
+		{0, 0, UnitsMinutes, ""},
+		{2 * time.Hour, 8 * time.Hour, UnitsPercentOfDay, "25%"},
+		{time.Hour, 3 * time.Hour, UnitsPercentOfTotal, "33.3%"},
+		{time.Hour, 0, UnitsPercentOfTotal, "1"}, // not a share, e.g. a balance
+		{0, 0, UnitsPercentOfTotal, ""},
+		{90 * time.Minute, 0, UnitsHM, "1:30"},
+	}
+	for _, tc := range testCases {
+		suite.Equal(tc.expected, formatShare(tc.duration, tc.whole, tc.units),
+			"duration: %v, units: %s", tc.duration, tc.units)
+	}
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_PercentUnits() {
+	report := getReport(suite.T(), `
+inc 20260101T140000Z - 20260101T170000Z # Work
+inc 20260101T170000Z - 20260101T180000Z # Admin
+inc 20260102T140000Z - 20260102T180000Z # Work
+`, nil, nil)
+	data, err := NewTimecardData(&report, TimecardOptions{
+		Units:           UnitsPercentOfDay,
+		IncludeTotalRow: true,
+		IncludeTotalCol: true,
+	})
+	suite.Require().NoError(err)
+	csv, err := data.StringCSV(',')
+	suite.Require().NoError(err)
+	suite.Equal(
+		"Tag,2026-01-01,2026-01-02,TOTAL\n"+
+			"Admin,25%,,12.5%\n"+
+			"Work,75%,100%,87.5%\n"+
+			"TOTAL,100%,100%,", csv)
+
+	// Balances are shown in decimal hours
+	data, err = NewTimecardData(&report, TimecardOptions{
+		Units:           UnitsPercentOfDay,
+		IncludeTotalCol: true,
+		ShowExpected:    true,
+		ExpectedWeekly:  40,
+	})
+	suite.Require().NoError(err)
+	csv, err = data.StringCSV(',')
+	suite.Require().NoError(err)
+	suite.Equal(
+		"Tag,2026-01-01,2026-01-02,TOTAL\n"+
+			"Admin,25%,,12.5%\n"+
+			"Work,75%,100%,87.5%\n"+
+			"EXPECTED,8,8,16\n"+
+			"ACTUAL,4,4,8\n"+
+			"DELTA,-4,-4,-8", csv)
+
+	data, err = NewTimecardData(&report, TimecardOptions{Units: UnitsPercentOfTotal})
+	suite.Require().NoError(err)
+	suite.Equal("37.5%", data.grid(false, data.formatCell, notesNone).records[2][1])
+	doc := data.JSON()
+	suite.Require().NotNil(doc.Rows[1].Days[1].Percent)
+	suite.Equal(50.0, *doc.Rows[1].Days[1].Percent)
+
+	_, err = NewTimecardData(&report, TimecardOptions{Units: "days"})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestStringCSV() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T163000Z # "Client, Inc"
+inc 20260101T163000Z - 20260101T170000Z # Admin
+inc 20260102T140000Z - 20260102T150000Z # Admin
+`,
+		nil,
+		nil,
+	)
+	data, err := NewTimecardData(&report, TimecardOptions{IncludeTotalRow: true, IncludeTotalCol: true})
+	suite.Require().NoError(err)
+
+	csv, err := data.StringCSV(',')
+	suite.Require().NoError(err)
+	suite.Equal(`Tag,2026-01-01,2026-01-02,TOTAL
+Admin,0.5,1,1.5
+"Client, Inc",2.5,,2.5
+TOTAL,3,1,`, csv)
+
+	data.options.Units = UnitsHM
+	tsv, err := data.StringCSV('\t')
+	suite.Require().NoError(err)
+	suite.Equal("Tag\t2026-01-01\t2026-01-02\tTOTAL\n"+
+		"Admin\t0:30\t1:00\t1:30\n"+
+		"Client, Inc\t2:30\t\t2:30\n"+
+		"TOTAL\t3:00\t1:00\t", tsv)
+}
+
+func (suite *TimecardTestSuite) TestStringJSON() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T163000Z # Admin Work
+inc 20260102T140000Z - 20260102T150500Z # Work
+`,
+		nil,
+		nil,
+	)
+	data, err := NewTimecardData(&report, TimecardOptions{Increment: 15})
+	suite.Require().NoError(err)
+
+	s, err := data.StringJSON()
+	suite.Require().NoError(err)
+	var doc JSONTimecard
+	suite.Require().NoError(json.Unmarshal([]byte(s), &doc))
+
+	suite.Equal(JSONSchemaVersion, doc.SchemaVersion)
+	suite.Equal(15, doc.IncrementMinutes)
+	suite.Require().NotNil(doc.Range)
+	suite.True(doc.Range.Start.Equal(time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC)))
+	suite.Equal([]string{"2026-01-01", "2026-01-02"}, doc.Dates)
+	suite.Require().Len(doc.Rows, 2)
+	suite.Equal("Work", doc.Rows[1].Tag)
+	suite.Equal(JSONDuration{Seconds: 9000, Hours: 2.5}, doc.Rows[1].Days[0])
+	suite.Equal(JSONDuration{Seconds: 4500, Hours: 1.25}, doc.Rows[1].Days[1])
+	suite.Equal(JSONDuration{Seconds: 0, Hours: 0}, doc.Rows[0].Days[1])
+	suite.Equal(3.75, doc.Rows[1].Total.Hours)
+	suite.Equal(3.75, doc.Total.Hours)
+	suite.Equal([]string{}, doc.Options.Filters)
+}
+
+func (suite *TimecardTestSuite) TestStringSpreadsheet() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260102T140000Z - 20260102T163000Z # Admin
+inc 20260105T140000Z - 20260105T150000Z # Admin
+inc 20260106T140000Z - 20260106T160000Z # R&D
+`,
+		nil,
+		nil,
+	)
+	delete(report.Config, "temp.report.start")
+	delete(report.Config, "temp.report.end")
+	data, err := NewTimecardData(&report, TimecardOptions{IncludeTotalRow: true, IncludeTotalCol: true})
+	suite.Require().NoError(err)
+
+	// One sheet per week
+	sheets := data.sheets()
+	suite.Require().Len(sheets, 2)
+	suite.Equal("Week of 2025-12-29", sheets[0].name)
+	suite.Equal([]string{"Tag", "2026-01-02", "TOTAL"}, sheets[0].header)
+	suite.Equal([]string{"Tag", "2026-01-05", "2026-01-06", "TOTAL"}, sheets[1].header)
+	suite.Equal([]cellRange{{"B2", "C2"}}, sheets[1].rowTotalRanges(0))
+	suite.Equal([]cellRange{{"D2", "D3"}}, sheets[1].columnTotalRanges(2))
+	suite.Equal("AB10", cellRef(27, 9))
+
+	readZip := func(s, name string) string {
+		z, err := zip.NewReader(strings.NewReader(s), int64(len(s)))
+		suite.Require().NoError(err)
+		f, err := z.Open(name)
+		suite.Require().NoError(err)
+		b, err := io.ReadAll(f)
+		suite.Require().NoError(err)
+		return string(b)
+	}
+
+	xlsx, err := data.StringXLSX()
+	suite.Require().NoError(err)
+	suite.Contains(readZip(xlsx, "xl/workbook.xml"), `<sheet name="Week of 2026-01-05" sheetId="2" r:id="rId2"/>`)
+	sheet := readZip(xlsx, "xl/worksheets/sheet2.xml")
+	suite.Contains(sheet, `<t xml:space="preserve">R&amp;D</t>`)
+	suite.Contains(sheet, `<c r="D3" s="3"><f>SUM(B3:C3)</f><v>2</v></c>`)
+	suite.Contains(sheet, `<c r="D4" s="3"><f>SUM(D2:D3)</f><v>3</v></c>`)
+
+	data.options.Units = UnitsHM
+	ods, err := data.StringODS()
+	suite.Require().NoError(err)
+	z, err := zip.NewReader(strings.NewReader(ods), int64(len(ods)))
+	suite.Require().NoError(err)
+	suite.Equal("mimetype", z.File[0].Name)
+	suite.Equal(zip.Store, z.File[0].Method)
+	content := readZip(ods, "content.xml")
+	suite.Equal(2, strings.Count(content, "<table:table "))
+	suite.Contains(content, `table:formula="of:=SUM([.D2:.D3])" office:value-type="time" office:time-value="PT3H0M0S"`)
+
+	data.options.Units = UnitsPercentOfDay
+	_, err = data.StringXLSX()
+	suite.ErrorContains(err, "not supported")
+	_, err = data.StringODS()
+	suite.ErrorContains(err, "not supported")
+}
+
+func (suite *TimecardTestSuite) TestStringSpreadsheet_MultipleTags() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260105T140000Z - 20260105T150000Z # Admin
+inc 20260106T140000Z - 20260106T160000Z # Admin Meeting
+`,
+		nil,
+		nil,
+	)
+	delete(report.Config, "temp.report.start")
+	delete(report.Config, "temp.report.end")
+	data, err := NewTimecardData(&report, TimecardOptions{IncludeTotalRow: true, IncludeTotalCol: true})
+	suite.Require().NoError(err)
+
+	// The two-tag interval is counted in both rows but only once in the
+	// TOTAL row, so its day's total is written as a value rather than a SUM
+	// of the rows
+	sheets := data.sheets()
+	suite.Require().Len(sheets, 1)
+	suite.Equal([]cellRange{{"B2", "B3"}}, sheets[0].columnTotalRanges(0))
+	suite.Nil(sheets[0].columnTotalRanges(1))
+	suite.Equal(2*time.Hour, sheets[0].columnTotal(1))
+	suite.Nil(sheets[0].columnTotalRanges(2))
+	suite.Equal(3*time.Hour, sheets[0].columnTotal(2))
+
+	csv, err := data.StringCSV(',')
+	suite.Require().NoError(err)
+	suite.True(strings.HasSuffix(csv, "TOTAL,1,2,"), csv)
+
+	xlsx, err := data.StringXLSX()
+	suite.Require().NoError(err)
+	z, err := zip.NewReader(strings.NewReader(xlsx), int64(len(xlsx)))
+	suite.Require().NoError(err)
+	f, err := z.Open("xl/worksheets/sheet1.xml")
+	suite.Require().NoError(err)
+	b, err := io.ReadAll(f)
+	suite.Require().NoError(err)
+	sheet := string(b)
+	suite.Contains(sheet, `<c r="B4" s="3"><f>SUM(B2:B3)</f><v>1</v></c>`)
+	suite.Contains(sheet, `<c r="C4" s="3"><v>2</v></c>`)
+	suite.Contains(sheet, `<c r="D4" s="3"><v>3</v></c>`)
+}
+
+func (suite *TimecardTestSuite) TestStringSpreadsheet_RoundedTotals() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260105T140000Z - 20260105T141000Z # Admin
+inc 20260106T140000Z - 20260106T141000Z # Admin
+inc 20260112T140000Z - 20260112T141000Z # Admin
+`,
+		nil,
+		nil,
+	)
+	delete(report.Config, "temp.report.start")
+	delete(report.Config, "temp.report.end")
+	options := TimecardOptions{
+		IncludeTotalRow: true,
+		IncludeTotalCol: true,
+		Increment:       15,
+		RoundingScope:   ScopeWeek,
+	}
+	data, err := NewTimecardData(&report, options)
+	suite.Require().NoError(err)
+
+	// Each week is rounded as a whole, so the cells don't add up to the row
+	// totals, which are written as values
+	sheets := data.sheets()
+	suite.Require().Len(sheets, 2)
+	suite.Nil(sheets[0].rowTotalRanges(0))
+	suite.Equal(30*time.Minute, sheets[0].rowTotal(0))
+	suite.Equal(15*time.Minute, sheets[1].rowTotal(0))
+
+	xlsx, err := data.StringXLSX()
+	suite.Require().NoError(err)
+	z, err := zip.NewReader(strings.NewReader(xlsx), int64(len(xlsx)))
+	suite.Require().NoError(err)
+	f, err := z.Open("xl/worksheets/sheet1.xml")
+	suite.Require().NoError(err)
+	b, err := io.ReadAll(f)
+	suite.Require().NoError(err)
+	suite.Contains(string(b), `<c r="D2" s="3"><v>0.5</v></c>`)
+
+	// A single sheet uses the row totals of the timecard
+	options.RoundingScope = ScopeTotal
+	options.Granularity = ByWeek
+	data, err = NewTimecardData(&report, options)
+	suite.Require().NoError(err)
+	sheets = data.sheets()
+	suite.Require().Len(sheets, 1)
+	suite.Equal(data.rowTotals["Admin"], sheets[0].rowTotal(0))
+	suite.Equal(30*time.Minute, sheets[0].rowTotal(0))
+
+	// Reconciled cells add up to the row totals
+	options.Reconcile = true
+	data, err = NewTimecardData(&report, options)
+	suite.Require().NoError(err)
+	suite.Equal([]cellRange{{"B2", "C2"}}, data.sheets()[0].rowTotalRanges(0))
+}
+
+func (suite *TimecardTestSuite) TestCellIntervals() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260105T140000Z - 20260105T150000Z # Admin
+inc 20260105T150000Z - 20260105T160000Z # Work # "review"
+inc 20260106T140000Z - 20260106T143000Z # Email
+`,
+		nil,
+		nil,
+	)
+	data, err := NewTimecardData(&report, TimecardOptions{Top: 2, Sort: SortTotal})
+	suite.Require().NoError(err)
+	view := data.TemplateData()
+	suite.Require().Len(view.Rows, 3)
+	suite.Equal(OtherRow, view.Rows[2].Tag)
+
+	intervals := data.CellIntervals(1, 0)
+	suite.Require().Len(intervals, 1)
+	suite.Equal([]string{"Work"}, intervals[0].Tags)
+	suite.Equal("review", intervals[0].Annotation)
+	suite.Equal(time.Hour, intervals[0].Duration)
+
+	// Collapsed rows keep their intervals
+	suite.Len(data.CellIntervals(2, 1), 1)
+
+	// Totals cover every row or column
+	suite.Len(data.CellIntervals(3, 0), 2)
+	suite.Len(data.CellIntervals(0, len(view.Columns)), 1)
+	suite.Len(data.CellIntervals(3, len(view.Columns)), 3)
+
+	table := data.StringTableCursor(1, 0)
+	suite.Contains(table, "Work")
+}
+
+func (suite *TimecardTestSuite) TestGolden() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T163000Z # "Client | Inc"
+inc 20260101T163000Z - 20260101T170000Z # Admin
+inc 20260102T140000Z - 20260102T150000Z # Admin "Code <Review>"
+`,
+		nil,
+		nil,
+	)
+	tcs := []struct {
+		golden  string
+		options TimecardOptions
+	}{
+		{"timecard.md", TimecardOptions{OutputFormat: "markdown"}},
+		{"timecard_totals.md", TimecardOptions{OutputFormat: "markdown", IncludeTotalRow: true, IncludeTotalCol: true}},
+		{"timecard.html", TimecardOptions{OutputFormat: "html"}},
+		{"timecard_totals.html", TimecardOptions{OutputFormat: "html", IncludeTotalRow: true, IncludeTotalCol: true}},
+		{"timecard_css.html", TimecardOptions{OutputFormat: "html", HTMLStyle: true, IncludeTotalRow: true}},
+	}
+	for _, tc := range tcs {
+		actual, err := Run(&report, tc.options)
+		suite.Require().NoError(err)
+		assertGolden(suite.T(), tc.golden, actual)
+	}
+}
+
 func (suite *TimecardTestSuite) TestRoundingFunc_6MinuteIncrement() {
 	round := getRoundingFunc(6)
 	tcs := [][]time.Duration{
@@ -191,6 +1212,28 @@ func (suite *TimecardTestSuite) TestRoundingFunc_6MinuteIncrement() {
 	}
 }
 
+// Compare actual against the named file in testdata/golden. Run `go test
+// -update` to rewrite the golden files.
+func assertGolden(t *testing.T, name string, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %s", err)
+	}
+	if string(expected) != actual {
+		t.Errorf("%s does not match golden file\n--- expected\n%s\n--- actual\n%s", name, expected, actual)
+	}
+}
+
 func getReport(t *testing.T, intervalString string, startDate *timew.Datetime, endDate *timew.Datetime) timew.Report {
 
 	intervals := getIntervals(t, intervalString)
@@ -231,3 +1274,219 @@ func getIntervals(t *testing.T, intervalString string) []timew.Interval {
 	}
 	return out
 }
+
+func (suite *TimecardTestSuite) TestRoundingModeFunc() {
+	tcs := []struct {
+		mode     string
+		input    time.Duration
+		expected time.Duration
+	}{
+		{RoundUp, 16 * time.Minute, 30 * time.Minute},
+		{RoundDown, 29 * time.Minute, 15 * time.Minute},
+		{RoundNearest, 22 * time.Minute, 15 * time.Minute},
+		{RoundNearest, 22*time.Minute + 30*time.Second, 30 * time.Minute},
+		{RoundBankers, 22*time.Minute + 30*time.Second, 30 * time.Minute},
+		{RoundBankers, 37*time.Minute + 30*time.Second, 30 * time.Minute},
+		{RoundBankers, 38 * time.Minute, 45 * time.Minute},
+	}
+	for _, tc := range tcs {
+		round, err := getRoundingModeFunc(tc.mode, 15)
+		suite.Require().NoError(err)
+		suite.Equal(tc.expected, round(tc.input), "%s %s", tc.mode, tc.input)
+	}
+	_, err := getRoundingModeFunc("sideways", 15)
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_RoundingScope() {
+	// Ten 2-minute interruptions on one day, plus 10 minutes the next day
+	intervals := ""
+	for i := range 10 {
+		intervals += fmt.Sprintf("inc 20260101T14%02d00Z - 20260101T14%02d00Z # Support\n", i*5, i*5+2)
+	}
+	intervals += "inc 20260102T140000Z - 20260102T141000Z # Support\n"
+	report := getReport(suite.T(), intervals, nil, nil)
+	day1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	day2 := time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)
+
+	tcs := []struct {
+		scope     string
+		reconcile bool
+		day1      time.Duration
+		day2      time.Duration
+		total     time.Duration
+	}{
+		{ScopeInterval, false, 150 * time.Minute, 15 * time.Minute, 165 * time.Minute},
+		{ScopeCell, false, 30 * time.Minute, 15 * time.Minute, 45 * time.Minute},
+		{ScopeTotal, false, 20 * time.Minute, 10 * time.Minute, 30 * time.Minute},
+		{ScopeTotal, true, 15 * time.Minute, 15 * time.Minute, 30 * time.Minute},
+		{ScopeCell, true, 15 * time.Minute, 15 * time.Minute, 30 * time.Minute},
+	}
+	for _, tc := range tcs {
+		data, err := NewTimecardData(&report, TimecardOptions{
+			Increment:     15,
+			RoundingScope: tc.scope,
+			Reconcile:     tc.reconcile,
+		})
+		suite.Require().NoError(err)
+		msg := fmt.Sprintf("scope=%s reconcile=%t", tc.scope, tc.reconcile)
+		suite.Equal(tc.day1, data.data["Support"][day1], msg)
+		suite.Equal(tc.day2, data.data["Support"][day2], msg)
+		suite.Equal(tc.total, data.rowTotals["Support"], msg)
+
+		// The TOTAL row adds up to the cells
+		suite.Equal(tc.day1, data.totals[day1], msg)
+		suite.Equal(tc.day2, data.totals[day2], msg)
+	}
+
+	_, err := NewTimecardData(&report, TimecardOptions{RoundingScope: "month"})
+	suite.Error(err)
+	_, err = NewTimecardData(&report, TimecardOptions{Increment: 15, Reconcile: true})
+	suite.ErrorContains(err, "reconciliation requires")
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_RoundingScopeWeek() {
+	// 20 minutes on each of two days in two weeks
+	report := getReport(
+		suite.T(),
+		`
+inc 20260108T140000Z - 20260108T142000Z # Support
+inc 20260109T140000Z - 20260109T142000Z # Support
+inc 20260112T140000Z - 20260112T142000Z # Support Admin
+inc 20260113T140000Z - 20260113T142000Z # Support
+`,
+		nil,
+		nil,
+	)
+	days := make([]time.Time, 4)
+	for i, day := range []int{8, 9, 12, 13} {
+		days[i] = time.Date(2026, 1, day, 0, 0, 0, 0, time.Local)
+	}
+
+	data, err := NewTimecardData(&report, TimecardOptions{Increment: 15, RoundingScope: ScopeWeek})
+	suite.Require().NoError(err)
+	suite.Equal(90*time.Minute, data.rowTotals["Support"])
+	suite.Equal(20*time.Minute, data.data["Support"][days[0]])
+	suite.Equal(30*time.Minute, data.rowTotals["Admin"])
+
+	data, err = NewTimecardData(&report, TimecardOptions{
+		Increment:       15,
+		RoundingScope:   ScopeWeek,
+		Reconcile:       true,
+		IncludeTotalRow: true,
+	})
+	suite.Require().NoError(err)
+	suite.Equal(90*time.Minute, data.rowTotals["Support"])
+	var cells []time.Duration
+	for _, day := range days {
+		cells = append(cells, data.data["Support"][day])
+	}
+	suite.Equal([]time.Duration{30 * time.Minute, 15 * time.Minute, 30 * time.Minute, 15 * time.Minute}, cells)
+
+	// The TOTAL row adds up to the cells, except on the day the interval is
+	// counted in both tags
+	g := data.grid(true, func(d, _ time.Duration) string { return formatDuration(d, UnitsHM) }, notesNone)
+	suite.Equal([]string{"TOTAL", "0:30", "0:15", "", "", "0:30", "0:15"}, g.records[len(g.records)-1])
+	suite.Equal([]string{"Admin", "", "", "", "", "0:30", ""}, g.records[1])
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Allocation() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T170000Z # Work ClientA Meeting
+inc 20260101T170000Z - 20260101T180000Z # Work
+`,
+		nil,
+		nil,
+	)
+	tcs := []struct {
+		options  TimecardOptions
+		expected map[string]time.Duration
+	}{
+		{
+			TimecardOptions{},
+			map[string]time.Duration{"Work": 4 * time.Hour, "ClientA": 3 * time.Hour, "Meeting": 3 * time.Hour},
+		},
+		{
+			TimecardOptions{Allocation: AllocateSplit},
+			map[string]time.Duration{"Work": 2 * time.Hour, "ClientA": time.Hour, "Meeting": time.Hour},
+		},
+		{
+			TimecardOptions{Allocation: AllocatePrimary},
+			map[string]time.Duration{"Work": 4 * time.Hour},
+		},
+		{
+			TimecardOptions{Allocation: AllocatePrimary, PrimaryPattern: "^Client"},
+			map[string]time.Duration{"Work": time.Hour, "ClientA": 3 * time.Hour},
+		},
+		{
+			TimecardOptions{Allocation: AllocatePrimary, PrimaryPattern: "^Client", PrimaryTags: []string{"Meeting"}},
+			map[string]time.Duration{"Work": time.Hour, "Meeting": 3 * time.Hour},
+		},
+		{
+			TimecardOptions{Allocation: AllocateSplit, Groups: []string{"Billable=ClientA,Meeting"}},
+			map[string]time.Duration{"Work": 2 * time.Hour, "Billable": 2 * time.Hour},
+		},
+	}
+	for _, tc := range tcs {
+		data, err := NewTimecardData(&report, tc.options)
+		suite.Require().NoError(err)
+		suite.Equal(tc.expected, data.rowTotals, "%+v", tc.options)
+		suite.Equal(4*time.Hour, data.totals[time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)])
+	}
+
+	data, err := NewTimecardData(&report, TimecardOptions{Allocation: AllocatePrimary, PrimaryPattern: "^Client"})
+	suite.Require().NoError(err)
+	suite.Contains(data.footer(), "Allocation: primary (time is counted in one tag: first tag matching ^Client, else first tag)")
+
+	_, err = NewTimecardData(&report, TimecardOptions{Allocation: "random"})
+	suite.Error(err)
+}
+
+func (suite *TimecardTestSuite) TestNewTimecardData_Granularity() {
+	report := getReport(
+		suite.T(),
+		`
+inc 20260101T140000Z - 20260101T150000Z # Admin
+inc 20260105T140000Z - 20260105T160000Z # Admin
+inc 20260106T140000Z - 20260106T150000Z # Work
+inc 20260202T140000Z - 20260202T150000Z # Work
+`,
+		nil,
+		nil,
+	)
+	// Without a range, only periods with data are shown
+	delete(report.Config, "temp.report.start")
+	delete(report.Config, "temp.report.end")
+	tcs := []struct {
+		granularity string
+		header      []string
+	}{
+		{ByDay, []string{"Tag", "2026-01-01", "2026-01-05", "2026-01-06", "2026-02-02"}},
+		{ByWeek, []string{"Tag", "2026-W01", "2026-W02", "2026-W06"}},
+		{ByMonth, []string{"Tag", "2026-01", "2026-02"}},
+	}
+	for _, tc := range tcs {
+		data, err := NewTimecardData(&report, TimecardOptions{Granularity: tc.granularity})
+		suite.Require().NoError(err)
+		g := data.grid(true, data.formatCell, notesNone)
+		suite.Equal(tc.header, g.records[0])
+	}
+
+	data, err := NewTimecardData(&report, TimecardOptions{Granularity: ByWeek, Transpose: true, IncludeTotalCol: true})
+	suite.Require().NoError(err)
+	g := data.grid(false, data.formatCell, notesNone)
+	suite.Equal([][]string{
+		{"Week", "Admin", "Work"},
+		{"W01 12/29", "1", "-"},
+		{"W02 01/05", "2", "1"},
+		{"W06 02/02", "-", "1"},
+		{"TOTAL", "3", "2"},
+	}, g.records)
+	suite.Equal(1, g.totalRows)
+	suite.Equal(0, g.totalCols)
+
+	_, err = NewTimecardData(&report, TimecardOptions{Granularity: "year"})
+	suite.Error(err)
+}
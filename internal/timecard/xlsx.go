@@ -0,0 +1,208 @@
+package timecard
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Styles of XLSX cells, i.e. indices into cellXfs in xlsxStyles
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleValue
+	xlsxStyleTotal
+	xlsxStyleBoldLabel
+)
+
+// StringXLSX renders the timecard as an Office Open XML workbook, with one
+// sheet per week for daily timecards (see sheets). Durations are written as
+// numbers in the configured units, and the total row and column as SUM
+// formulas. Percentage units are not supported.
+func (td TimecardData) StringXLSX() (string, error) {
+	if err := checkSheetUnits(td.options.Units); err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	if err := writeXLSX(&builder, td.sheets(), td.options.Units); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func writeXLSX(w io.Writer, sheets []sheet, units string) error {
+	type part struct {
+		name    string
+		content string
+	}
+	parts := []part{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles(units)},
+	}
+	for i, s := range sheets {
+		parts = append(parts, part{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheet(s, units)})
+	}
+	z := zip.NewWriter(w)
+	for _, p := range parts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const xlsxRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func xlsxContentTypes(nsheets int) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= nsheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func xlsxWorkbook(sheets []sheet) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(s.name), i+1, i+1)
+	}
+	// Recalculate formulas when the workbook is opened
+	b.WriteString(`</sheets><calcPr fullCalcOnLoad="1"/></workbook>`)
+	return b.String()
+}
+
+func xlsxWorkbookRels(nsheets int) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= nsheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, nsheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// Returns the stylesheet, with a bold, shaded header and durations formatted
+// in the given units.
+func xlsxStyles(units string) string {
+	// Built-in number formats: 2 is 0.00 and 1 is 0
+	numFmt := 2
+	switch units {
+	case UnitsHM:
+		numFmt = 164
+	case UnitsMinutes:
+		numFmt = 1
+	}
+	return xmlHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="[h]:mm"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+		`<fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="5">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+		fmt.Sprintf(`<xf numFmtId="%d" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`, numFmt) +
+		fmt.Sprintf(`<xf numFmtId="%d" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>`, numFmt) +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+}
+
+// Returns the worksheet of a sheet, with the header row frozen.
+func xlsxSheet(s sheet, units string) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	b.WriteString(`<cols>`)
+	for j := range s.labelCols {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, j+1, j+1, s.labelWidth(j))
+	}
+	if len(s.header) > s.labelCols {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="12" customWidth="1"/>`, s.labelCols+1, len(s.header))
+	}
+	b.WriteString(`</cols><sheetData>`)
+
+	b.WriteString(`<row r="1">`)
+	for j, label := range s.header {
+		b.WriteString(xlsxString(cellRef(j, 0), label, xlsxStyleHeader))
+	}
+	b.WriteString(`</row>`)
+
+	for i, row := range s.rows {
+		labelStyle, valueStyle := xlsxStyleDefault, xlsxStyleValue
+		if row.subtotal {
+			labelStyle, valueStyle = xlsxStyleBoldLabel, xlsxStyleTotal
+		}
+		fmt.Fprintf(&b, `<row r="%d">`, i+2)
+		for j, label := range row.labels {
+			b.WriteString(xlsxString(cellRef(j, i+1), label, labelStyle))
+		}
+		for j, d := range row.values {
+			if d != 0 {
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, cellRef(s.labelCols+j, i+1), valueStyle, formatNumber(sheetValue(d, units)))
+			}
+		}
+		if s.totalCol {
+			b.WriteString(xlsxFormula(cellRef(s.labelCols+s.valueCols(), i+1), s.rowTotalRanges(i), s.rowTotal(i), units))
+		}
+		b.WriteString(`</row>`)
+	}
+
+	if s.totalRow {
+		r := len(s.rows) + 1
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		b.WriteString(xlsxString(cellRef(0, r), "TOTAL", xlsxStyleBoldLabel))
+		for j := range s.valueCols() + boolInt(s.totalCol) {
+			b.WriteString(xlsxFormula(cellRef(s.labelCols+j, r), s.columnTotalRanges(j), s.columnTotal(j), units))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// Returns a cell containing an inline string.
+func xlsxString(ref, value string, style int) string {
+	return fmt.Sprintf(`<c r="%s" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escapeXML(value))
+}
+
+// Returns a total cell containing a SUM formula of the given ranges, with the
+// current total as its cached value.
+func xlsxFormula(ref string, ranges []cellRange, total time.Duration, units string) string {
+	value := formatNumber(sheetValue(total, units))
+	if len(ranges) == 0 {
+		return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleTotal, value)
+	}
+	refs := make([]string, len(ranges))
+	for i, r := range ranges {
+		refs[i] = r.String()
+	}
+	return fmt.Sprintf(`<c r="%s" s="%d"><f>SUM(%s)</f><v>%s</v></c>`, ref, xlsxStyleTotal, strings.Join(refs, ","), value)
+}
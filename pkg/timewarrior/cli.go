@@ -66,6 +66,16 @@ func (cli *CLI) Delete(id int) error {
 	return err
 }
 
+// Calls `timew get <reference>` and returns the result with surrounding
+// whitespace removed (e.g. `dom.rc.<setting>` returns a configuration value).
+func (cli *CLI) Get(reference string) (string, error) {
+	output, err := cli.runCommand("get", reference)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // Calls `timew modify start|end @<id> <value>`.
 func (cli *CLI) Modify(id int, field string, value string) error {
 	_, err := cli.runCommand("modify", field, fmt.Sprintf("@%d", id), value, ":adjust")
@@ -26,9 +26,10 @@ func NewIntervalFromString(value string) (Interval, error) {
 	// inc <start> # <tags> # "<annotation>"
 	// inc <start> - <end> # <tags>
 	// inc <start> - <end> # <tags> # "<annotation>"
+	// Untagged intervals have no tags section (e.g. inc <start> - <end>).
 	var (
-		reClosed = regexp.MustCompile(`^inc (\d{8}T\d{6}Z) - (\d{8}T\d{6}Z) # ?(.*?)(?: # "((?:[^"\\]|\\.)*)")?$`)
-		reOpen   = regexp.MustCompile(`^inc (\d{8}T\d{6}Z) # ?(.*?)(?: # "((?:[^"\\]|\\.)*)")?$`)
+		reClosed = regexp.MustCompile(`^inc (\d{8}T\d{6}Z) - (\d{8}T\d{6}Z)(?: # ?(.*?)(?: # "((?:[^"\\]|\\.)*)")?)?$`)
+		reOpen   = regexp.MustCompile(`^inc (\d{8}T\d{6}Z)(?: # ?(.*?)(?: # "((?:[^"\\]|\\.)*)")?)?$`)
 	)
 
 	if matches := reClosed.FindStringSubmatch(value); matches != nil {
@@ -116,8 +117,9 @@ func (interval Interval) DatabaseString() string {
 // Return a new Interval where the start and end time locations are set to the local timezone.
 func (interval Interval) Localize() Interval {
 	out := Interval{
-		ID:   interval.ID,
-		Tags: interval.Tags,
+		ID:         interval.ID,
+		Tags:       interval.Tags,
+		Annotation: interval.Annotation,
 	}
 	if interval.Start != nil {
 		start := interval.Start.Local()
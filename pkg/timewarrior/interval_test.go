@@ -70,6 +70,18 @@ func (suite *IntervalSuite) TestIntervalFromString_Open_WithAnnotation() {
 	suite.Equal("This is \"my annotation\" you see", interval.Annotation)
 }
 
+func (suite *IntervalSuite) TestIntervalFromString_Untagged() {
+	interval, err := NewIntervalFromString(`inc 20260101T000000Z - 20260101T010000Z`)
+	suite.NoError(err)
+	suite.Equal(*interval.End, Datetime{time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC)})
+	suite.Empty(interval.Tags)
+
+	interval, err = NewIntervalFromString(`inc 20260101T000000Z`)
+	suite.NoError(err)
+	suite.Nil(interval.End)
+	suite.Empty(interval.Tags)
+}
+
 func (suite *IntervalSuite) TestLocalize() {
 	value := `inc 20260101T000000Z - 20260101T010000Z # Test "Code Review"`
 	interval, err := NewIntervalFromString(value)
@@ -80,6 +92,13 @@ func (suite *IntervalSuite) TestLocalize() {
 	suite.Equal(*localInterval.End, Datetime{time.Date(2025, 12, 31, 20, 0, 0, 0, time.Local)})
 }
 
+func (suite *IntervalSuite) TestLocalize_KeepsAnnotation() {
+	value := `inc 20260101T000000Z - 20260101T010000Z # Test # "Some notes"`
+	interval, err := NewIntervalFromString(value)
+	suite.NoError(err)
+	suite.Equal("Some notes", interval.Localize().Annotation)
+}
+
 func (suite *IntervalSuite) TestContains_True() {
 	interval, err := NewIntervalFromString(`inc 20260101T000000Z - 20260101T010000Z # Test "Code Review"`)
 	suite.NoError(err)
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Report contains the data passed to a Timewarrior report via the [Extension API].
@@ -24,7 +26,7 @@ func NewReport(reader io.Reader) (*Report, error) {
 	scanner := bufio.NewScanner(reader)
 
 	// Read config + intervals
-	configPattern := regexp.MustCompile(`([a-z\.]*): (.*)`)
+	configPattern := regexp.MustCompile(`^([A-Za-z0-9_./-]+): (.*)$`)
 	jsonPattern := regexp.MustCompile(`({.*})`)
 	intervals := make([]Interval, 0)
 	config := map[string]string{}
@@ -131,3 +133,23 @@ func (tw *Report) getConfigAsDatetime(field string) (Datetime, error) {
 	}
 	return out, nil
 }
+
+// Returns a copy of the report restricted to the range from start (inclusive)
+// to end (exclusive): only intervals overlapping the range are kept, and
+// `temp.report.start` and `temp.report.end` are set to the range. Intervals
+// are not clipped to the range.
+func (tw *Report) Slice(start, end time.Time) *Report {
+	config := maps.Clone(tw.Config)
+	config["temp.report.start"] = Datetime{start.UTC()}.String()
+	config["temp.report.end"] = Datetime{end.UTC()}.String()
+	intervals := make([]Interval, 0)
+	for _, interval := range tw.Intervals {
+		if interval.Start.Before(end) && (interval.End == nil || interval.End.After(start)) {
+			intervals = append(intervals, interval)
+		}
+	}
+	return &Report{
+		Config:    config,
+		Intervals: intervals,
+	}
+}
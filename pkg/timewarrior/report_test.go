@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -36,6 +37,25 @@ func (suite *TWReportSuite) TestNewTimewarrior() {
 	suite.Len(suite.tw.Intervals, 3)
 }
 
+func (suite *TWReportSuite) TestNewReport_Config() {
+	reader := strings.NewReader(`temp.report.start: 20260101T050000Z
+twe.group.Clients/ClientA: ^acme-.*
+reports.week.lines: 1
+
+[
+{"id":1,"start":"20260101T050000Z","end":"20260101T110000Z","tags":["Sleep"],"annotation":"note: zzz"}
+]`)
+	tw, err := NewReport(reader)
+	suite.Require().NoError(err)
+	suite.Equal(map[string]string{
+		"temp.report.start":         "20260101T050000Z",
+		"twe.group.Clients/ClientA": "^acme-.*",
+		"reports.week.lines":        "1",
+	}, tw.Config)
+	suite.Require().Len(tw.Intervals, 1)
+	suite.Equal("note: zzz", tw.Intervals[0].Annotation)
+}
+
 func (suite *TWReportSuite) TestLast() {
 	last, err := suite.tw.Last()
 	suite.NoError(err)
@@ -56,3 +76,17 @@ func (suite *TWReportSuite) TestDateBounds() {
 	require.NoError(err)
 	require.Greater(tf.Time, ti.Time)
 }
+
+func (suite *TWReportSuite) TestSlice() {
+	start := time.Date(2026, 1, 6, 11, 15, 0, 0, time.UTC)
+	slice := suite.tw.Slice(start, start.Add(30*time.Minute))
+	suite.Equal("20260106T111500Z", slice.Config["temp.report.start"])
+	suite.Equal("20260106T114500Z", slice.Config["temp.report.end"])
+	suite.Require().Len(slice.Intervals, 2)
+	suite.Equal(2, slice.Intervals[0].ID)
+	suite.Equal(1, slice.Intervals[1].ID)
+
+	// The original report is left as is
+	suite.Equal("20260106T050000Z", suite.tw.Config["temp.report.start"])
+	suite.Len(suite.tw.Intervals, 3)
+}